@@ -1,8 +1,10 @@
 package ishell
 
 import (
+	"bufio"
 	"fmt"
 	"io"
+	"io/fs"
 	"os/exec"
 	"runtime"
 	"strings"
@@ -21,6 +23,13 @@ type Actions interface {
 	ReadPassword() string
 	// ReadPasswordErr is ReadPassword but returns error as well
 	ReadPasswordErr() (string, error)
+	// ReadPasswordWithOptions is ReadPasswordErr with custom masking and
+	// a per-keystroke callback.
+	ReadPasswordWithOptions(opts PasswordOptions) (string, error)
+	// ReadCode reads exactly length characters from standard input. Characters not
+	// in charset are rejected and input is submitted once length characters are read.
+	// This is useful for one-time passwords e.g. ReadCode(6, "0123456789").
+	ReadCode(length int, charset string) (string, error)
 	// ReadMultiLinesFunc reads multiple lines from standard input. It passes each read line to
 	// f and stops reading when f returns false.
 	ReadMultiLinesFunc(f func(string) bool) string
@@ -34,6 +43,8 @@ type Actions interface {
 	Print(val ...interface{})
 	// Printf prints to output using string format.
 	Printf(format string, val ...interface{})
+	// Flush writes buffered output to the output writer, see Shell.BufferOutput.
+	Flush() error
 	// ShowPaged shows a paged text that is scrollable.
 	// This leverages on "less" for unix and "more" for windows.
 	ShowPaged(text string) error
@@ -65,8 +76,37 @@ type Actions interface {
 	Cmds() []*Cmd
 	// HelpText returns the computed help of top level commands.
 	HelpText() string
+	// SearchCmds searches all commands for term in their names, aliases
+	// and help texts, ignoring case.
+	SearchCmds(term string) []CmdSearchResult
 	// ClearScreen clears the screen. Same behaviour as running 'clear' in unix terminal or 'cls' in windows cmd.
 	ClearScreen() error
+	// HideCursor hides the terminal cursor.
+	HideCursor() error
+	// ShowCursor shows the terminal cursor.
+	ShowCursor() error
+	// MoveCursorTo moves the terminal cursor to row and col. The top left corner is 1, 1.
+	MoveCursorTo(row, col int) error
+	// CursorPosition returns the position of the terminal cursor. The top left corner is 1, 1.
+	// An error is returned if the terminal does not report the position in time.
+	CursorPosition() (row, col int, err error)
+	// ClearLine clears the line of the cursor and moves the cursor to the start of the line.
+	ClearLine() error
+	// Dir returns the working directory of the shell.
+	Dir() string
+	// SetDir sets the working directory of the shell.
+	SetDir(dir string) error
+	// AbsPath resolves path against the working directory of the shell.
+	AbsPath(path string) string
+	// ReadDir reads the directory at path, relative to the working directory
+	// of the shell, from the shell's filesystem.
+	ReadDir(path string) ([]fs.DirEntry, error)
+	// Identity returns the identity of the user of the shell.
+	Identity() Identity
+	// PushMode enters a nested mode with its own commands.
+	PushMode(name string, root *Cmd)
+	// PopMode exits the current mode. It returns false if the shell is not in a mode.
+	PopMode() bool
 	// Stop stops the shell. This will stop the shell from auto reading inputs and calling
 	// registered functions. A stopped shell is only inactive but totally functional.
 	// Its functions can still be called and can be restarted.
@@ -95,13 +135,24 @@ func (s *shellActionsImpl) ReadLineWithDefault(defaultValue string) string {
 }
 
 func (s *shellActionsImpl) ReadPassword() string {
+	s.Flush()
 	return s.reader.readPassword()
 }
 
 func (s *shellActionsImpl) ReadPasswordErr() (string, error) {
+	s.Flush()
 	return s.reader.readPasswordErr()
 }
 
+func (s *shellActionsImpl) ReadPasswordWithOptions(opts PasswordOptions) (string, error) {
+	s.Flush()
+	return s.reader.readPasswordWithOptions(opts)
+}
+
+func (s *shellActionsImpl) ReadCode(length int, charset string) (string, error) {
+	return s.readCode(length, charset)
+}
+
 func (s *shellActionsImpl) ReadMultiLinesFunc(f func(string) bool) string {
 	lines, _ := s.readMultiLinesFunc(f)
 	return lines
@@ -117,20 +168,29 @@ func (s *shellActionsImpl) ReadMultiLines(terminator string) string {
 }
 
 func (s *shellActionsImpl) Println(val ...interface{}) {
-	s.reader.buf.Truncate(0)
+	s.reader.buf.Reset()
 	fmt.Fprintln(s.writer, val...)
 }
 
 func (s *shellActionsImpl) Print(val ...interface{}) {
-	s.reader.buf.Truncate(0)
+	// format once, the buffer is also used by the reader
+	// to detect a prompt printed before reading input.
+	s.reader.buf.Reset()
 	fmt.Fprint(s.reader.buf, val...)
-	fmt.Fprint(s.writer, val...)
+	s.writer.Write(s.reader.buf.Bytes())
 }
 
 func (s *shellActionsImpl) Printf(format string, val ...interface{}) {
-	s.reader.buf.Truncate(0)
+	s.reader.buf.Reset()
 	fmt.Fprintf(s.reader.buf, format, val...)
-	fmt.Fprintf(s.writer, format, val...)
+	s.writer.Write(s.reader.buf.Bytes())
+}
+
+func (s *shellActionsImpl) Flush() error {
+	if w, ok := s.writer.(*bufio.Writer); ok && s.unbufferedWriter != nil {
+		return w.Flush()
+	}
+	return nil
 }
 
 func (s *shellActionsImpl) MultiChoice(options []string, text string) int {
@@ -141,8 +201,8 @@ func (s *shellActionsImpl) Checklist(options []string, text string, init []int)
 	return s.multiChoice(options, text, init, true)
 }
 func (s *shellActionsImpl) SetPrompt(prompt string) {
-	s.reader.prompt = prompt
-	s.reader.scanner.SetPrompt(s.reader.rlPrompt())
+	s.basePrompt = prompt
+	s.applyPrompt()
 }
 
 func (s *shellActionsImpl) SetMultiPrompt(prompt string) {
@@ -175,6 +235,26 @@ func (s *shellActionsImpl) ClearScreen() error {
 	return clearScreen(s.Shell)
 }
 
+func (s *shellActionsImpl) HideCursor() error {
+	return hideCursor(s.Shell)
+}
+
+func (s *shellActionsImpl) ShowCursor() error {
+	return showCursor(s.Shell)
+}
+
+func (s *shellActionsImpl) MoveCursorTo(row, col int) error {
+	return moveCursorTo(s.Shell, row, col)
+}
+
+func (s *shellActionsImpl) CursorPosition() (row, col int, err error) {
+	return s.cursorPosition()
+}
+
+func (s *shellActionsImpl) ClearLine() error {
+	return clearLine(s.Shell)
+}
+
 func (s *shellActionsImpl) ShowPaged(text string) error {
 	return showPagedReader(s.Shell, strings.NewReader(text))
 }
@@ -183,15 +263,31 @@ func (s *shellActionsImpl) ShowPagedReader(r io.Reader) error {
 	return showPagedReader(s.Shell, r)
 }
 
+func (s *shellActionsImpl) AbsPath(path string) string {
+	return s.absPath(path)
+}
+
+func (s *shellActionsImpl) ReadDir(path string) ([]fs.DirEntry, error) {
+	return s.readDir(s.absPath(path))
+}
+
 func (s *shellActionsImpl) Stop() {
 	s.stop()
 }
 
 func (s *shellActionsImpl) HelpText() string {
-	return s.rootCmd.HelpText()
+	return s.rootCmd.helpText(s.cmdAvailable)
+}
+
+func (s *shellActionsImpl) SearchCmds(term string) []CmdSearchResult {
+	return s.rootCmd.search(term, s.cmdAvailable)
 }
 
 func showPagedReader(s *Shell, r io.Reader) error {
+	if s.pagerDisabled {
+		_, err := io.Copy(s.writer, r)
+		return err
+	}
 	var cmd *exec.Cmd
 
 	if s.pager == "" {
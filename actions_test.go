@@ -0,0 +1,37 @@
+package ishell_test
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/abiosoft/ishell/v2"
+)
+
+func BenchmarkPrint(b *testing.B) {
+	shell := ishell.New()
+	shell.SetOut(ioutil.Discard)
+	line := strings.Repeat("output ", 10)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		shell.Print(line, i, "\n")
+	}
+}
+
+func BenchmarkPrintf(b *testing.B) {
+	shell := ishell.New()
+	shell.SetOut(ioutil.Discard)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		shell.Printf("line %d of %s\n", i, "output")
+	}
+}
+
+func BenchmarkPrintln(b *testing.B) {
+	shell := ishell.New()
+	shell.SetOut(ioutil.Discard)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		shell.Println("line", i, "of output")
+	}
+}
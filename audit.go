@@ -0,0 +1,133 @@
+package ishell
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"sync"
+	"time"
+)
+
+// AuditEntry is an entry in an audit log.
+type AuditEntry struct {
+	// Time is when the command was executed.
+	Time time.Time `json:"time"`
+	// Session identifies the session that executed the command.
+	Session string `json:"session,omitempty"`
+	// User is the user that executed the command.
+	User string `json:"user,omitempty"`
+	// Source is the address the session originates from.
+	Source string `json:"source,omitempty"`
+	// Command is the executed command and its arguments.
+	Command []string `json:"command"`
+	// Error is the error returned by the command, if any.
+	Error string `json:"error,omitempty"`
+	// Prev is the signature of the previous entry.
+	Prev string `json:"prev"`
+	// Sig is the signature of this entry.
+	Sig string `json:"sig"`
+}
+
+// AuditLog is an append-only log of executed commands.
+// Each entry is a line of JSON signed together with the signature of the
+// previous entry. Modifying, removing or reordering entries breaks the
+// chain of signatures and is detected by VerifyAuditLog.
+type AuditLog struct {
+	// Session identifies the session in the log entries.
+	Session string
+
+	w    io.Writer
+	key  []byte
+	prev string
+	sync.Mutex
+}
+
+// NewAuditLog creates an audit log that writes to w. Entries are signed
+// with HMAC-SHA256 using key or hashed with SHA-256 if key is empty.
+// Use a key to prevent the log from being rewritten entirely.
+func NewAuditLog(w io.Writer, key []byte) *AuditLog {
+	return &AuditLog{w: w, key: key}
+}
+
+// Record appends an entry for command executed by identity to the log.
+func (a *AuditLog) Record(identity Identity, command []string, cmdErr error) error {
+	a.Lock()
+	defer a.Unlock()
+	entry := AuditEntry{
+		Time:    time.Now(),
+		Session: a.Session,
+		User:    identity.User,
+		Source:  identity.Source,
+		Command: command,
+		Prev:    a.prev,
+	}
+	if cmdErr != nil {
+		entry.Error = cmdErr.Error()
+	}
+	sig, err := signAuditEntry(entry, a.key)
+	if err != nil {
+		return err
+	}
+	entry.Sig = sig
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if _, err := a.w.Write(append(b, '\n')); err != nil {
+		return err
+	}
+	a.prev = sig
+	return nil
+}
+
+// VerifyAuditLog verifies the entries in an audit log read from r
+// using key. It returns an error describing the first invalid entry.
+func VerifyAuditLog(r io.Reader, key []byte) error {
+	scanner := bufio.NewScanner(r)
+	prev := ""
+	for line := 1; scanner.Scan(); line++ {
+		var entry AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return fmt.Errorf("audit log line %d: %v", line, err)
+		}
+		if entry.Prev != prev {
+			return fmt.Errorf("audit log line %d: broken chain", line)
+		}
+		sig, err := signAuditEntry(entry, key)
+		if err != nil {
+			return err
+		}
+		if !hmac.Equal([]byte(sig), []byte(entry.Sig)) {
+			return fmt.Errorf("audit log line %d: invalid signature", line)
+		}
+		prev = entry.Sig
+	}
+	return scanner.Err()
+}
+
+func signAuditEntry(entry AuditEntry, key []byte) (string, error) {
+	entry.Sig = ""
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return "", err
+	}
+	var h hash.Hash
+	if len(key) > 0 {
+		h = hmac.New(sha256.New, key)
+	} else {
+		h = sha256.New()
+	}
+	h.Write(b)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// SetAuditLog sets the audit log for recording every executed input.
+// Use nil to disable auditing.
+func (s *Shell) SetAuditLog(a *AuditLog) {
+	s.auditLog = a
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"sort"
+	"strings"
 	"text/tabwriter"
 )
 
@@ -35,8 +36,78 @@ type Cmd struct {
 	// CompleterWithPrefix takes precedence
 	CompleterWithPrefix func(prefix string, args []string) []string
 
+	// Params describes the positional arguments of the command.
+	// Arguments with allowed values are autocompleted and validated
+	// before Func is called.
+	Params []Param
+
+	// Available reports if the command can be used in the current
+	// state of the shell e.g. only after a connection is established.
+	// Unavailable commands are excluded from help and autocomplete
+	// and fail with UnavailableErr when executed.
+	// A nil Available means the command is always available.
+	Available func(c *Context) bool
+
+	// UnavailableErr is the error reported when the command is executed
+	// while unavailable. Defaults to a generic error.
+	UnavailableErr error
+
+	// Annotations is metadata for extensions such as middleware,
+	// authorization and documentation generators.
+	// ishell does not use it.
+	Annotations map[string]string
+
 	// subcommands.
 	children map[string]*Cmd
+	// sorted names of subcommands for completion, reset when subcommands change.
+	names []string
+	// computed help, shared with copies of the command.
+	help *helpCache
+}
+
+// helpCache is the computed help of a command with all its subcommands.
+type helpCache struct {
+	// fields of the command the text was computed with.
+	name, help, longHelp string
+	text                 string
+	valid                bool
+}
+
+// Param is a positional argument of a command.
+type Param struct {
+	// Name of the argument.
+	Name string
+	// Help message for the argument.
+	Help string
+	// Values are the allowed values of the argument.
+	// Any value is allowed if empty.
+	Values []string
+}
+
+// validateArgs validates args against the allowed values of the params.
+func (c *Cmd) validateArgs(args []string) error {
+	for i, arg := range args {
+		if i >= len(c.Params) {
+			break
+		}
+		if !c.Params[i].allows(arg) {
+			return fmt.Errorf("invalid %s '%s', expected one of: %s",
+				c.Params[i].Name, arg, strings.Join(c.Params[i].Values, ", "))
+		}
+	}
+	return nil
+}
+
+func (p Param) allows(value string) bool {
+	if len(p.Values) == 0 {
+		return true
+	}
+	for _, v := range p.Values {
+		if v == value {
+			return true
+		}
+	}
+	return false
 }
 
 // AddCmd adds cmd as a subcommand.
@@ -45,11 +116,81 @@ func (c *Cmd) AddCmd(cmd *Cmd) {
 		c.children = make(map[string]*Cmd)
 	}
 	c.children[cmd.Name] = cmd
+	c.names = nil
+	if c.help == nil {
+		c.help = &helpCache{}
+	}
+	c.help.valid = false
 }
 
 // DeleteCmd deletes cmd from subcommands.
 func (c *Cmd) DeleteCmd(name string) {
 	delete(c.children, name)
+	c.names = nil
+	if c.help != nil {
+		c.help.valid = false
+	}
+}
+
+// childNames returns the sorted names of the subcommands starting with prefix.
+// The returned slice must not be modified.
+func (c *Cmd) childNames(prefix string) []string {
+	if c.names == nil && len(c.children) > 0 {
+		c.names = make([]string, 0, len(c.children))
+		for name := range c.children {
+			c.names = append(c.names, name)
+		}
+		sort.Strings(c.names)
+	}
+	i := sort.SearchStrings(c.names, prefix)
+	j := i
+	for j < len(c.names) && strings.HasPrefix(c.names[j], prefix) {
+		j++
+	}
+	return c.names[i:j:j]
+}
+
+// DeleteCmdPath deletes the subcommand at path e.g. []string{"remote", "add"}.
+// It returns false if there is no subcommand at path.
+func (c *Cmd) DeleteCmdPath(path []string) bool {
+	if len(path) == 0 {
+		return false
+	}
+	parent := c
+	for _, name := range path[:len(path)-1] {
+		if parent = parent.findChildCmd(name); parent == nil {
+			return false
+		}
+	}
+	cmd := parent.findChildCmd(path[len(path)-1])
+	if cmd == nil {
+		return false
+	}
+	parent.DeleteCmd(cmd.Name)
+	return true
+}
+
+// RenameCmd renames the subcommand named old to new.
+// new is removed from the aliases of the subcommand if present.
+func (c *Cmd) RenameCmd(old, new string) error {
+	cmd, ok := c.children[old]
+	if !ok {
+		return fmt.Errorf("command '%s' not found", old)
+	}
+	if _, ok := c.children[new]; ok {
+		return fmt.Errorf("command '%s' already exists", new)
+	}
+	var aliases []string
+	for _, alias := range cmd.Aliases {
+		if alias != new {
+			aliases = append(aliases, alias)
+		}
+	}
+	cmd.Aliases = aliases
+	c.DeleteCmd(old)
+	cmd.Name = new
+	c.AddCmd(cmd)
+	return nil
 }
 
 // Children returns the subcommands of c.
@@ -62,18 +203,49 @@ func (c *Cmd) Children() []*Cmd {
 	return cmds
 }
 
-func (c *Cmd) hasSubcommand() bool {
-	if len(c.children) > 1 {
-		return true
+// filterChildren returns the sorted subcommands of c accepted by filter.
+// All subcommands are returned if filter is nil.
+func (c *Cmd) filterChildren(filter func(*Cmd) bool) []*Cmd {
+	var cmds []*Cmd
+	for _, cmd := range c.Children() {
+		if filter == nil || filter(cmd) {
+			cmds = append(cmds, cmd)
+		}
 	}
-	if _, ok := c.children["help"]; !ok {
-		return len(c.children) > 0
+	return cmds
+}
+
+func hasSubcommand(children []*Cmd) bool {
+	if len(children) > 1 {
+		return true
 	}
-	return false
+	return len(children) == 1 && children[0].Name != "help"
 }
 
 // HelpText returns the computed help of the command and its subcommands.
+// The help is cached until subcommands are added or deleted.
 func (c Cmd) HelpText() string {
+	return c.helpText(nil)
+}
+
+// helpText returns the computed help of the command and the subcommands
+// accepted by filter.
+func (c *Cmd) helpText(filter func(*Cmd) bool) string {
+	cache := c.help
+	if cache != nil && filter != nil {
+		for _, child := range c.children {
+			if !filter(child) {
+				cache = nil
+				break
+			}
+		}
+	}
+	if cache != nil && cache.valid &&
+		cache.name == c.Name && cache.help == c.Help && cache.longHelp == c.LongHelp {
+		return cache.text
+	}
+
+	children := c.filterChildren(filter)
 	var b bytes.Buffer
 	p := func(s ...interface{}) {
 		fmt.Fprintln(&b)
@@ -88,15 +260,18 @@ func (c Cmd) HelpText() string {
 	} else if c.Name != "" {
 		p(c.Name, "has no help")
 	}
-	if c.hasSubcommand() {
+	if hasSubcommand(children) {
 		p("Commands:")
 		w := tabwriter.NewWriter(&b, 0, 4, 2, ' ', 0)
-		for _, child := range c.Children() {
+		for _, child := range children {
 			fmt.Fprintf(w, "\t%s\t\t\t%s\n", child.Name, child.Help)
 		}
 		w.Flush()
 		p()
 	}
+	if cache != nil {
+		*cache = helpCache{name: c.Name, help: c.Help, longHelp: c.LongHelp, text: b.String(), valid: true}
+	}
 	return b.String()
 }
 
@@ -119,6 +294,100 @@ func (c *Cmd) findChildCmd(name string) *Cmd {
 	return nil
 }
 
+// CmdSearchResult is a command found by a search.
+type CmdSearchResult struct {
+	// Path is the names of the command and its parents.
+	Path []string
+	Cmd  *Cmd
+}
+
+// search returns the descendants of c accepted by filter whose name,
+// aliases or help text contain term, ignoring case.
+func (c *Cmd) search(term string, filter func(*Cmd) bool) []CmdSearchResult {
+	term = strings.ToLower(term)
+	var results []CmdSearchResult
+	var walk func(cmd *Cmd, path []string)
+	walk = func(cmd *Cmd, path []string) {
+		for _, child := range cmd.filterChildren(filter) {
+			childPath := append(append([]string{}, path...), child.Name)
+			if child.matches(term) {
+				results = append(results, CmdSearchResult{Path: childPath, Cmd: child})
+			}
+			walk(child, childPath)
+		}
+	}
+	walk(c, nil)
+	return results
+}
+
+// matches reports if the name, aliases or help text of c contain the
+// lower case term, ignoring case.
+func (c *Cmd) matches(term string) bool {
+	texts := append([]string{c.Name, c.Help, c.LongHelp}, c.Aliases...)
+	for _, text := range texts {
+		if strings.Contains(strings.ToLower(text), term) {
+			return true
+		}
+	}
+	return false
+}
+
+// suggest returns the subcommands accepted by filter with names similar
+// to name, most similar first.
+func (c *Cmd) suggest(name string, filter func(*Cmd) bool) []*Cmd {
+	type suggestion struct {
+		cmd      *Cmd
+		distance int
+	}
+	var suggestions []suggestion
+	for _, cmd := range c.filterChildren(filter) {
+		d := editDistance(name, cmd.Name)
+		if d <= 2 || strings.HasPrefix(cmd.Name, name) {
+			suggestions = append(suggestions, suggestion{cmd, d})
+		}
+	}
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return suggestions[i].distance < suggestions[j].distance
+	})
+	cmds := make([]*Cmd, len(suggestions))
+	for i := range suggestions {
+		cmds[i] = suggestions[i].cmd
+	}
+	return cmds
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	prev := make([]int, len(t)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := range s {
+		cur := make([]int, len(t)+1)
+		cur[0] = i + 1
+		for j := range t {
+			cost := 1
+			if s[i] == t[j] {
+				cost = 0
+			}
+			cur[j+1] = minInt(prev[j+1]+1, cur[j]+1, prev[j]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(t)]
+}
+
+func minInt(n ...int) int {
+	m := n[0]
+	for _, i := range n[1:] {
+		if i < m {
+			m = i
+		}
+	}
+	return m
+}
+
 // FindCmd finds the matching Cmd for args.
 // It returns the Cmd and the remaining args.
 func (c Cmd) FindCmd(args []string) (*Cmd, []string) {
@@ -134,6 +403,20 @@ func (c Cmd) FindCmd(args []string) (*Cmd, []string) {
 	return cmd, nil
 }
 
+// findPath returns the names of the commands matched by args.
+func (c Cmd) findPath(args []string) []string {
+	var path []string
+	for _, arg := range args {
+		cmd := c.findChildCmd(arg)
+		if cmd == nil {
+			break
+		}
+		path = append(path, cmd.Name)
+		c = *cmd
+	}
+	return path
+}
+
 type cmdSorter []*Cmd
 
 func (c cmdSorter) Len() int           { return len(c) }
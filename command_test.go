@@ -1,6 +1,7 @@
 package ishell_test
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/abiosoft/ishell/v2"
@@ -86,6 +87,32 @@ func TestHelpText(t *testing.T) {
 	assert.Equal(t, res, expected)
 }
 
+func TestHelpTextCache(t *testing.T) {
+	cmd := newCmd("root", "help for root command")
+	cmd.AddCmd(newCmd("child1", "help for child1 command"))
+	assert.Contains(t, cmd.HelpText(), "child1")
+
+	cmd.AddCmd(newCmd("child2", "help for child2 command"))
+	assert.Contains(t, cmd.HelpText(), "child2", "refreshed after add")
+
+	cmd.DeleteCmd("child1")
+	assert.NotContains(t, cmd.HelpText(), "child1", "refreshed after delete")
+
+	cmd.Help = "new help"
+	assert.Contains(t, cmd.HelpText(), "new help", "refreshed after help change")
+}
+
+func BenchmarkHelpText(b *testing.B) {
+	cmd := newCmd("root", "help for root command")
+	for i := 0; i < 10000; i++ {
+		cmd.AddCmd(newCmd(fmt.Sprintf("child%05d", i), "help for child command"))
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		cmd.HelpText()
+	}
+}
+
 func TestChildrenSortedAlphabetically(t *testing.T) {
 	cmd := newCmd("root", "help for root command")
 	cmd.AddCmd(newCmd("child2", "help for child1 command"))
@@ -94,3 +121,28 @@ func TestChildrenSortedAlphabetically(t *testing.T) {
 	assert.Equal(t, children[0].Name, "child1", "must be first")
 	assert.Equal(t, children[1].Name, "child2", "must be second")
 }
+
+func TestDeleteCommandPath(t *testing.T) {
+	cmd := newCmd("root", "")
+	child := newCmd("child", "")
+	child.AddCmd(newCmd("grandchild", ""))
+	cmd.AddCmd(child)
+	assert.False(t, cmd.DeleteCmdPath([]string{"child", "missing"}))
+	assert.True(t, cmd.DeleteCmdPath([]string{"child", "grandchild"}))
+	assert.Equal(t, len(child.Children()), 0, "should be empty")
+}
+
+func TestRenameCommand(t *testing.T) {
+	cmd := newCmd("root", "")
+	child := newCmd("child", "")
+	child.Aliases = []string{"kid"}
+	cmd.AddCmd(child)
+	cmd.AddCmd(newCmd("other", ""))
+	assert.Error(t, cmd.RenameCmd("child", "other"))
+	assert.NoError(t, cmd.RenameCmd("child", "kid"))
+	res, _ := cmd.FindCmd([]string{"kid"})
+	assert.Equal(t, res, child)
+	assert.Empty(t, child.Aliases)
+	res, _ = cmd.FindCmd([]string{"child"})
+	assert.Nil(t, res)
+}
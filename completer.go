@@ -1,22 +1,28 @@
 package ishell
 
 import (
+	"sort"
 	"strings"
-
-	"github.com/flynn-archive/go-shlex"
 )
 
 type iCompleter struct {
-	cmd      *Cmd
-	disabled func() bool
+	root      func() *Cmd
+	disabled  func() bool
+	available func(*Cmd) bool
+	split     func(string) ([]string, error)
+	count     func(cmdPath []string) int
 }
 
 func (ic iCompleter) Do(line []rune, pos int) (newLine [][]rune, length int) {
 	if ic.disabled != nil && ic.disabled() {
 		return nil, len(line)
 	}
+	split := ic.split
+	if split == nil {
+		split = splitWords
+	}
 	var words []string
-	if w, err := shlex.Split(string(line)); err == nil {
+	if w, err := split(string(line)); err == nil {
 		words = w
 	} else {
 		// fall back
@@ -25,29 +31,63 @@ func (ic iCompleter) Do(line []rune, pos int) (newLine [][]rune, length int) {
 
 	var cWords []string
 	prefix := ""
-	if len(words) > 0 && pos > 0 && line[pos-1] != ' ' {
+	if len(words) > 0 && pos > 0 && pos <= len(line) && line[pos-1] != ' ' {
 		prefix = words[len(words)-1]
-		cWords = ic.getWords(prefix, words[:len(words)-1])
-	} else {
-		cWords = ic.getWords(prefix, words)
+		words = words[:len(words)-1]
 	}
+	cWords = ic.getWords(prefix, words)
 
-	var suggestions [][]rune
+	var matches []string
 	for _, w := range cWords {
 		if strings.HasPrefix(w, prefix) {
-			suggestions = append(suggestions, []rune(strings.TrimPrefix(w, prefix)))
+			matches = append(matches, w)
 		}
 	}
+	if ic.count != nil {
+		ic.sortByCount(words, matches)
+	}
+
+	suggestions := make([][]rune, len(matches))
+	for i, w := range matches {
+		suggestions[i] = []rune(w[len(prefix):])
+	}
 	if len(suggestions) == 1 && prefix != "" && string(suggestions[0]) == "" {
 		suggestions = [][]rune{[]rune(" ")}
 	}
 	return suggestions, len(prefix)
 }
 
+// sortByCount sorts candidate subcommands of the command matched by words,
+// most used first.
+func (ic iCompleter) sortByCount(words []string, candidates []string) {
+	if len(candidates) < 2 {
+		return
+	}
+	path := ic.root().findPath(words)
+	cmdPath := path[:len(path):len(path)]
+	counts := make(map[string]int)
+	for _, c := range candidates {
+		if n := ic.count(append(cmdPath, c)); n > 0 {
+			counts[c] = n
+		}
+	}
+	if len(counts) == 0 {
+		return
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if counts[a] != counts[b] {
+			return counts[a] > counts[b]
+		}
+		return a < b
+	})
+}
+
 func (ic iCompleter) getWords(prefix string, w []string) (s []string) {
-	cmd, args := ic.cmd.FindCmd(w)
+	root := ic.root()
+	cmd, args := root.FindCmd(w)
 	if cmd == nil {
-		cmd, args = ic.cmd, w
+		cmd, args = root, w
 	}
 	if cmd.CompleterWithPrefix != nil {
 		return cmd.CompleterWithPrefix(prefix, args)
@@ -55,8 +95,17 @@ func (ic iCompleter) getWords(prefix string, w []string) (s []string) {
 	if cmd.Completer != nil {
 		return cmd.Completer(args)
 	}
-	for k := range cmd.children {
-		s = append(s, k)
+	if len(args) < len(cmd.Params) && len(cmd.Params[len(args)].Values) > 0 {
+		return cmd.Params[len(args)].Values
+	}
+	names := cmd.childNames(prefix)
+	if ic.available == nil {
+		return names
+	}
+	for _, name := range names {
+		if ic.available(cmd.children[name]) {
+			s = append(s, name)
+		}
 	}
 	return
 }
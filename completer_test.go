@@ -0,0 +1,36 @@
+package ishell_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/abiosoft/ishell/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompleteSubcommands(t *testing.T) {
+	shell := ishell.New()
+	shell.AddCmd(&ishell.Cmd{Name: "alpha"})
+	shell.AddCmd(&ishell.Cmd{Name: "alps"})
+	shell.AddCmd(&ishell.Cmd{Name: "beta"})
+	assert.Equal(t, []string{"pha", "ps"}, shell.Complete("al"), "subcommands with prefix")
+
+	shell.DeleteCmd("alps")
+	assert.Equal(t, []string{" "}, shell.Complete("alpha"), "index rebuilt after delete")
+
+	shell.AddCmd(&ishell.Cmd{Name: "alpine"})
+	assert.Equal(t, []string{"ha", "ine"}, shell.Complete("alp"), "index rebuilt after add")
+}
+
+func BenchmarkComplete(b *testing.B) {
+	shell := ishell.New()
+	for i := 0; i < 10000; i++ {
+		shell.AddCmd(&ishell.Cmd{Name: fmt.Sprintf("cmd%05d", i)})
+	}
+	shell.Complete("cmd")
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		shell.Complete("cmd0999")
+	}
+}
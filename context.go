@@ -1,10 +1,13 @@
 package ishell
 
+import "sync"
+
 // Context is an ishell context. It embeds ishell.Actions.
 type Context struct {
 	contextValues
 	progressBar ProgressBar
 	err         error
+	notFound    *NotFoundInfo
 
 	// Args is command arguments.
 	Args []string
@@ -29,33 +32,74 @@ func (c *Context) ProgressBar() ProgressBar {
 	return c.progressBar
 }
 
+// NotFoundInfo returns details about the input that could not be matched
+// to a command. It is nil outside of the NotFound handler.
+func (c *Context) NotFoundInfo() *NotFoundInfo {
+	return c.notFound
+}
+
+// NotFoundInfo describes input that could not be matched to a command.
+type NotFoundInfo struct {
+	// Name is the attempted command name.
+	Name string
+	// Args is the remaining input after Name.
+	Args []string
+	// Nearest is the command most similar to Name, nil if there is none.
+	Nearest *Cmd
+	// Suggestions are the names of commands similar to Name, most similar first.
+	Suggestions []string
+}
+
 // contextValues is the map for values in the context.
-type contextValues map[string]interface{}
+// It is safe for concurrent use.
+type contextValues struct {
+	values map[string]interface{}
+	mutex  sync.RWMutex
+}
 
 // Get returns the value associated with this context for key, or nil
 // if no value is associated with key. Successive calls to Get with
 // the same key returns the same result.
-func (c contextValues) Get(key string) interface{} {
-	return c[key]
+func (c *contextValues) Get(key string) interface{} {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.values[key]
 }
 
 // Set sets the key in this context to value.
 func (c *contextValues) Set(key string, value interface{}) {
-	if *c == nil {
-		*c = make(map[string]interface{})
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.values == nil {
+		c.values = make(map[string]interface{})
 	}
-	(*c)[key] = value
+	c.values[key] = value
 }
 
 // Del deletes key and its value in this context.
-func (c contextValues) Del(key string) {
-	delete(c, key)
+func (c *contextValues) Del(key string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.values, key)
 }
 
 // Keys returns all keys in the context.
-func (c contextValues) Keys() (keys []string) {
-	for key := range c {
+func (c *contextValues) Keys() (keys []string) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	for key := range c.values {
 		keys = append(keys, key)
 	}
 	return
 }
+
+// copy returns a snapshot of the values.
+func (c *contextValues) copy() map[string]interface{} {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	values := make(map[string]interface{}, len(c.values))
+	for k, v := range c.values {
+		values[k] = v
+	}
+	return values
+}
@@ -0,0 +1,58 @@
+package ishell_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/abiosoft/ishell/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextValues(t *testing.T) {
+	shell := ishell.New()
+	shell.Set("key", "shell")
+	shell.AddCmd(&ishell.Cmd{
+		Name: "set",
+		Func: func(c *ishell.Context) {
+			assert.Equal(t, "shell", c.Get("key"))
+			c.Set("key", "context")
+			c.Del("missing")
+		},
+	})
+	assert.NoError(t, shell.Process("set"))
+	assert.Equal(t, "shell", shell.Get("key"), "context values should be a copy")
+	assert.Equal(t, []string{"key"}, shell.Keys())
+}
+
+func TestContextValuesConcurrent(t *testing.T) {
+	shell := ishell.New()
+	shell.AddCmd(&ishell.Cmd{
+		Name: "read",
+		Func: func(c *ishell.Context) {
+			var wg sync.WaitGroup
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					c.Set(fmt.Sprint(i), i)
+					c.Get(fmt.Sprint(i))
+					c.Keys()
+				}(i)
+			}
+			wg.Wait()
+		},
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			shell.Set(fmt.Sprint(i), i)
+			shell.Del(fmt.Sprint(i - 1))
+		}(i)
+		shell.Process("read")
+	}
+	wg.Wait()
+}
@@ -0,0 +1,31 @@
+package ishell
+
+import (
+	"io"
+	"strings"
+
+	"github.com/abiosoft/readline"
+)
+
+// Complete returns the completions of line with the cursor at the end.
+func (s *Shell) Complete(line string) []string {
+	var words []string
+	suggestions, _ := iCompleter{root: s.RootCmd, available: s.cmdAvailable, split: s.split, count: s.cmdCount}.
+		Do([]rune(line), len([]rune(line)))
+	for _, w := range suggestions {
+		words = append(words, string(w))
+	}
+	return words
+}
+
+// ReadInput reads and splits input the way the shell reads commands.
+func ReadInput(input string) ([]string, error) {
+	s := NewWithConfig(&readline.Config{
+		Stdin:          io.NopCloser(strings.NewReader(input)),
+		Stdout:         io.Discard,
+		Stderr:         io.Discard,
+		FuncIsTerminal: func() bool { return false },
+	})
+	defer s.Close()
+	return s.read()
+}
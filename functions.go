@@ -1,17 +1,48 @@
 package ishell
 
 import (
+	"fmt"
 	"os"
+	"strings"
+	"text/tabwriter"
 )
 
 func exitFunc(c *Context) {
+	if c.PopMode() {
+		return
+	}
 	c.Stop()
 }
 
 func helpFunc(c *Context) {
+	if len(c.Args) > 0 && c.Args[0] == "--search" {
+		helpSearchFunc(c)
+		return
+	}
 	c.Println(c.HelpText())
 }
 
+func helpSearchFunc(c *Context) {
+	if len(c.Args) < 2 {
+		c.Println("usage: help --search <term>")
+		return
+	}
+	term := strings.ToLower(strings.Join(c.Args[1:], " "))
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 4, 2, ' ', 0)
+	found := false
+	for _, result := range c.SearchCmds(term) {
+		fmt.Fprintf(w, "  %s\t\t%s\n", strings.Join(result.Path, " "), result.Cmd.Help)
+		found = true
+	}
+	w.Flush()
+	if !found {
+		c.Println("no commands found for", term)
+		return
+	}
+	c.Print(b.String())
+}
+
 func clearFunc(c *Context) {
 	err := c.ClearScreen()
 	if err != nil {
@@ -26,22 +57,53 @@ func addDefaultFuncs(s *Shell) {
 		Func: exitFunc,
 	})
 	s.AddCmd(&Cmd{
-		Name: "help",
-		Help: "display help",
-		Func: helpFunc,
+		Name:     "help",
+		Help:     "display help",
+		LongHelp: "display help.\nuse 'help --search <term>' to search commands.",
+		Func:     helpFunc,
 	})
 	s.AddCmd(&Cmd{
 		Name: "clear",
 		Help: "clear the screen",
 		Func: clearFunc,
 	})
-	s.Interrupt(interruptFunc)
+	s.AddCmd(&Cmd{
+		Name:     "terminal",
+		Help:     "show or change terminal settings",
+		LongHelp: errTerminalUsage.Error(),
+		Func:     terminalFunc(s),
+		Params: []Param{
+			{Name: "setting", Values: []string{"width", "color", "pager"}},
+		},
+	})
+	s.SetInterruptPolicy(InterruptExit(2))
 }
 
-func interruptFunc(c *Context, count int, line string) {
-	if count >= 2 {
+// InterruptPolicy handles keyboard interrupts (Ctrl-c), see Shell.Interrupt.
+type InterruptPolicy func(c *Context, count int, input string)
+
+var (
+	// InterruptStop stops the shell on interrupt without exiting the program.
+	InterruptStop InterruptPolicy = func(c *Context, count int, input string) {
 		c.Println("Interrupted")
-		os.Exit(1)
+		c.Stop()
+	}
+	// InterruptIgnore ignores interrupts.
+	InterruptIgnore InterruptPolicy = func(c *Context, count int, input string) {}
+)
+
+// InterruptExit exits the program with status 1 after n consecutive
+// interrupts. This is the default with n of 2.
+func InterruptExit(n int) InterruptPolicy {
+	return func(c *Context, count int, input string) {
+		if count >= n {
+			c.Println("Interrupted")
+			os.Exit(1)
+		}
+		if n-count == 1 {
+			c.Println("Input Ctrl-c once more to exit")
+			return
+		}
+		c.Printf("Input Ctrl-c %d more times to exit\n", n-count)
 	}
-	c.Println("Input Ctrl-c once more to exit")
 }
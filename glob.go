@@ -0,0 +1,86 @@
+package ishell
+
+import (
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// ExpandGlobs sets if unquoted arguments with glob patterns
+// e.g. *.log should be expanded to the matching files.
+// Quoted or escaped patterns are not expanded and patterns without
+// matches are left as is. Defaults to false.
+func (s *Shell) ExpandGlobs(expand bool) {
+	s.expandGlobs = expand
+}
+
+// expandArgs expands the glob patterns in args. line is the input args are
+// split from and is used to skip quoted arguments.
+func (s *Shell) expandArgs(line string, args []string) []string {
+	quoted := quotedArgs(line)
+	if len(quoted) != len(args) {
+		// line is not split the regular way, expansion is unsafe.
+		return args
+	}
+	var expanded []string
+	for i, arg := range args {
+		if quoted[i] || !strings.ContainsAny(arg, "*?[") {
+			expanded = append(expanded, arg)
+			continue
+		}
+		matches, err := s.glob(s.absPath(arg))
+		if err != nil || len(matches) == 0 {
+			expanded = append(expanded, arg)
+			continue
+		}
+		for _, match := range matches {
+			// keep relative patterns relative to the working directory.
+			if !filepath.IsAbs(arg) && !strings.HasPrefix(arg, "/") {
+				if rel, err := s.relPath(match); err == nil {
+					match = rel
+				}
+			}
+			expanded = append(expanded, match)
+		}
+	}
+	return expanded
+}
+
+// quotedArgs splits line into words the same way as the default splitter and
+// reports for each word if it contains quotes or escapes.
+func quotedArgs(line string) []bool {
+	var quoted []bool
+	inWord, inQuote := false, rune(0)
+	escaped := false
+	for _, r := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\' && inQuote != '\'':
+			escaped = true
+		case inQuote != 0:
+			if r == inQuote {
+				inQuote = 0
+			}
+			continue
+		case r == '"' || r == '\'':
+			inQuote = r
+		case unicode.IsSpace(r):
+			inWord = false
+			continue
+		default:
+			if !inWord {
+				inWord = true
+				quoted = append(quoted, false)
+			}
+			continue
+		}
+		// quote or escape
+		if !inWord {
+			inWord = true
+			quoted = append(quoted, false)
+		}
+		quoted[len(quoted)-1] = true
+	}
+	return quoted
+}
@@ -0,0 +1,144 @@
+package ishell
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+)
+
+const historyChunkSize = 64 * 1024
+
+// history is the input history of the shell.
+// readline keeps its history per config and loses it when the config is
+// replaced, the shell keeps its own copy to restore it. The history file
+// is append-only and only the most recent lines are read from it.
+type history struct {
+	file  string
+	lines []string
+}
+
+// historyLimit returns the maximum number of lines kept in memory.
+func (s *Shell) historyLimit() int {
+	limit := s.reader.scanner.Config.HistoryLimit
+	if limit == 0 {
+		limit = 500
+	}
+	return limit
+}
+
+// addHistory adds line to the history and appends it to the history file.
+func (s *Shell) addHistory(line string) {
+	line = strings.TrimSpace(line)
+	if line == "" || s.historyLimit() < 0 {
+		return
+	}
+	s.history.lines = append(s.history.lines, line)
+	if extra := len(s.history.lines) - s.historyLimit(); extra > 0 {
+		s.history.lines = s.history.lines[extra:]
+	}
+	if s.history.file == "" {
+		return
+	}
+	f, err := os.OpenFile(s.history.file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	f.WriteString(line + "\n")
+}
+
+// restoreHistory loads the history into readline after its config is replaced.
+func (s *Shell) restoreHistory() {
+	s.reader.scanner.ResetHistory()
+	for _, line := range s.history.lines {
+		s.reader.scanner.SaveHistory(line)
+	}
+}
+
+// setHistoryFile sets the history file and loads the most recent lines from it.
+func (s *Shell) setHistoryFile(path string) {
+	s.history = history{file: path}
+	if path != "" {
+		s.history.lines, _ = readLastLines(path, s.historyLimit())
+	}
+	s.restoreHistory()
+}
+
+// readLastLines reads the last n non-empty lines of the file at path.
+// The file is read backwards in chunks so that the time taken depends on n
+// and not on the size of the file.
+func readLastLines(path string, n int) ([]string, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	var partial []byte
+	chunk := make([]byte, historyChunkSize)
+	for offset := info.Size(); offset > 0 && len(lines) < n; {
+		size := int64(len(chunk))
+		if offset < size {
+			size = offset
+		}
+		offset -= size
+		if _, err := f.ReadAt(chunk[:size], offset); err != nil && err != io.EOF {
+			return nil, err
+		}
+		data := append(chunk[:size:size], partial...)
+		start := 0
+		if offset > 0 {
+			// the first line of the chunk may continue in the previous chunk.
+			i := bytes.IndexByte(data, '\n')
+			if i < 0 {
+				partial = data
+				continue
+			}
+			start = i + 1
+		}
+		partial = append([]byte{}, data[:start]...)
+		lines = appendLinesReversed(lines, data[start:], n)
+	}
+	if len(lines) < n {
+		lines = appendLinesReversed(lines, partial, n)
+	}
+	// reverse to chronological order.
+	for i, j := 0, len(lines)-1; i < j; i, j = i+1, j-1 {
+		lines[i], lines[j] = lines[j], lines[i]
+	}
+	return lines, nil
+}
+
+// appendLinesReversed appends the non-empty lines in data to lines
+// from the last, until lines has n lines.
+func appendLinesReversed(lines []string, data []byte, n int) []string {
+	fields := bytes.Split(data, []byte{'\n'})
+	for i := len(fields) - 1; i >= 0 && len(lines) < n; i-- {
+		if line := bytes.TrimSpace(fields[i]); len(line) > 0 {
+			lines = append(lines, string(line))
+		}
+	}
+	return lines
+}
+
+// disableReadlineHistoryFile stops readline from managing the history file
+// given in its config, the shell manages the file instead.
+func (s *Shell) disableReadlineHistoryFile() {
+	path := s.reader.scanner.Config.HistoryFile
+	if path == "" {
+		return
+	}
+	config := s.reader.scanner.Config.Clone()
+	config.HistoryFile = ""
+	s.reader.scanner.SetConfig(config)
+	s.setHistoryFile(path)
+}
@@ -0,0 +1,31 @@
+package ishell_test
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/abiosoft/ishell/v2"
+)
+
+func BenchmarkSetHistoryPath(b *testing.B) {
+	path := filepath.Join(b.TempDir(), "history")
+	f, err := os.Create(path)
+	if err != nil {
+		b.Fatal(err)
+	}
+	w := bufio.NewWriter(f)
+	for i := 0; i < 100000; i++ {
+		fmt.Fprintf(w, "command %d with some arguments\n", i)
+	}
+	w.Flush()
+	f.Close()
+
+	shell := ishell.New()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		shell.SetHistoryPath(path)
+	}
+}
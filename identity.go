@@ -0,0 +1,34 @@
+package ishell
+
+// Identity is the identity of the user of a shell session.
+type Identity struct {
+	// User is the name of the user.
+	User string
+	// Roles are the roles of the user for authorization.
+	Roles []string
+	// Source is the address the session originates from,
+	// empty for local sessions.
+	Source string
+}
+
+// HasRole reports if the identity has role.
+func (i Identity) HasRole(role string) bool {
+	for _, r := range i.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// SetIdentity sets the identity of the user of the shell.
+// The identity is available to commands with Context.Identity
+// and is included in audit log entries.
+func (s *Shell) SetIdentity(identity Identity) {
+	s.identity = identity
+}
+
+// Identity returns the identity of the user of the shell.
+func (s *Shell) Identity() Identity {
+	return s.identity
+}
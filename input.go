@@ -0,0 +1,128 @@
+package ishell
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/abiosoft/readline"
+)
+
+// InputFilter filters input runes before they are processed by the shell.
+// It returns the (possibly translated) rune and false if the rune should be
+// discarded.
+type InputFilter func(r rune) (rune, bool)
+
+type inputFilters struct {
+	filters []*InputFilter
+	sync.RWMutex
+}
+
+func (f *inputFilters) add(filter InputFilter) *InputFilter {
+	f.Lock()
+	defer f.Unlock()
+	p := &filter
+	f.filters = append(f.filters, p)
+	return p
+}
+
+func (f *inputFilters) remove(p *InputFilter) {
+	f.Lock()
+	defer f.Unlock()
+	for i := range f.filters {
+		if f.filters[i] == p {
+			f.filters = append(f.filters[:i], f.filters[i+1:]...)
+			return
+		}
+	}
+}
+
+// filter passes r through the filters in the order they were added.
+// It stops at the first filter that discards the rune.
+func (f *inputFilters) filter(r rune) (rune, bool) {
+	f.RLock()
+	defer f.RUnlock()
+	for _, filter := range f.filters {
+		var ok bool
+		if r, ok = (*filter)(r); !ok {
+			return r, false
+		}
+	}
+	return r, true
+}
+
+// AddInputFilter adds a filter for input runes. Filters are chained in the
+// order they are added and the output of a filter is the input of the next.
+// It returns a function that removes the filter.
+func (s *Shell) AddInputFilter(filter InputFilter) (remove func()) {
+	p := s.inputFilters.add(filter)
+	s.UpdateConfig(func(config *readline.Config) {
+		config.FuncFilterInputRune = s.inputFilters.filter
+	})
+	return func() { s.inputFilters.remove(p) }
+}
+
+// PasswordOptions are the options for reading masked input.
+type PasswordOptions struct {
+	// Mask is the character displayed in place of each input character.
+	// Nothing is displayed if Mask is 0 and ShowLast is false.
+	Mask rune
+	// ShowLast displays the last typed character unmasked.
+	// Mask defaults to '*' if this is set.
+	ShowLast bool
+	// OnChange is called with the current input after every keystroke.
+	// This can be used to display a password strength meter.
+	OnChange func(input []rune)
+}
+
+// maskPainter masks all characters apart from the one before the cursor.
+type maskPainter rune
+
+func (m maskPainter) Paint(line []rune, pos int) []rune {
+	masked := make([]rune, len(line))
+	for i := range line {
+		if i == pos-1 {
+			masked[i] = line[i]
+		} else {
+			masked[i] = rune(m)
+		}
+	}
+	return masked
+}
+
+func (s *Shell) readCode(length int, charset string) (string, error) {
+	if length <= 0 {
+		return "", nil
+	}
+	var line []rune
+	var last rune
+	conf := s.reader.scanner.Config.Clone()
+	conf.DisableAutoSaveHistory = true
+	conf.FuncFilterInputRune = func(r rune) (rune, bool) {
+		switch r {
+		case 0, readline.CharInterrupt, readline.CharDelete,
+			readline.CharBackspace, readline.CharCtrlH:
+			return r, true
+		}
+		if !strings.ContainsRune(charset, r) || len(line) >= length {
+			return r, false
+		}
+		if len(line) == length-1 {
+			// submit as soon as the code is complete.
+			last = r
+			return readline.CharEnter, true
+		}
+		return r, true
+	}
+	conf.SetListener(func(l []rune, pos int, key rune) ([]rune, int, bool) {
+		line = l
+		return nil, 0, false
+	})
+	oldconf := s.reader.scanner.SetConfig(conf)
+	defer s.reader.scanner.SetConfig(oldconf)
+
+	code, err := s.readLine()
+	if last != 0 {
+		code += string(last)
+	}
+	return code, err
+}
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"log"
 	"os"
 	"os/user"
@@ -15,12 +16,10 @@ import (
 	"strings"
 	"sync"
 	"time"
-	"unicode"
 	"unicode/utf8"
 
 	"github.com/abiosoft/readline"
 	"github.com/fatih/color"
-	shlex "github.com/flynn-archive/go-shlex"
 )
 
 const (
@@ -31,6 +30,7 @@ const (
 var (
 	errNoHandler          = errors.New("incorrect input, try 'help'")
 	errNoInterruptHandler = errors.New("no interrupt handler")
+	errCmdUnavailable     = errors.New("command not available")
 	strMultiChoice        = " ❯"
 	strMultiChoiceWin     = " >"
 	strMultiChoiceSpacer  = " "
@@ -53,12 +53,38 @@ type Shell struct {
 	customCompleter   bool
 	multiChoiceActive bool
 	haltChan          chan struct{}
-	historyFile       string
+	history           history
 	autoHelp          bool
 	rawArgs           []string
 	progressBar       ProgressBar
 	pager             string
 	pagerArgs         []string
+	configMutex       sync.Mutex
+	inputFilters      inputFilters
+	tempCmds          []tempCmd
+	exitCodeMapper    func(error) int
+	defaultCmd        *Cmd
+	splitter          func(string) ([]string, error)
+	expandGlobs       bool
+	dir               string
+	fsys              fs.FS
+	auditLog          *AuditLog
+	identity          Identity
+	usage             *cmdUsage
+	boostFrequent     bool
+	lastCommand       []string
+	repeatPending     bool
+	removeRepeatKey   func()
+	validator         func(string) error
+	width             int
+	pagerDisabled     bool
+	basePrompt        string
+	modes             []mode
+	breadcrumb        func(string, []string) string
+	clearOnInterrupt  bool
+	stopHooks         []func()
+	termState         *terminalState
+	unbufferedWriter  io.Writer
 	contextValues
 	Actions
 }
@@ -91,10 +117,13 @@ func NewWithReadline(rl *readline.Instance) *Shell {
 			buf:         &bytes.Buffer{},
 			completer:   readline.NewPrefixCompleter(),
 		},
-		writer:   rl.Config.Stdout,
-		autoHelp: true,
+		writer:     rl.Config.Stdout,
+		autoHelp:   true,
+		basePrompt: rl.Config.Prompt,
+		termState:  saveTerminalState(),
 	}
 	shell.Actions = &shellActionsImpl{Shell: shell}
+	shell.disableReadlineHistoryFile()
 	shell.progressBar = newProgressBar(shell)
 	addDefaultFuncs(shell)
 	return shell
@@ -134,12 +163,17 @@ func (s *Shell) SetRootCmd(cmd *Cmd) {
 }
 
 func (s *Shell) stop() {
-	if !s.Active() {
+	s.activeMutex.Lock()
+	if !s.active {
+		s.activeMutex.Unlock()
 		return
 	}
-	s.activeMutex.Lock()
 	s.active = false
 	s.activeMutex.Unlock()
+	s.removeTempCmds(true)
+	for _, f := range s.stopHooks {
+		f()
+	}
 	close(s.haltChan)
 }
 
@@ -149,6 +183,7 @@ func (s *Shell) stop() {
 func (s *Shell) Close() {
 	s.stop()
 	s.reader.scanner.Close()
+	s.RestoreTerminal()
 }
 
 func (s *Shell) prepareRun() {
@@ -166,10 +201,13 @@ func (s *Shell) prepareRun() {
 }
 
 func (s *Shell) run() {
+	defer s.restoreOnPanic()
 shell:
 	for s.Active() {
 		var line []string
 		var err error
+		// discard repeats requested while commands read input.
+		s.repeatPending = false
 		read := make(chan struct{})
 		go func() {
 			line, err = s.read()
@@ -196,6 +234,12 @@ shell:
 			continue
 		}
 
+		if err == readline.ErrInterrupt && s.clearOnInterrupt && len(line) > 0 {
+			// discard the input line
+			s.interruptCount = 0
+			continue
+		}
+
 		if err == readline.ErrInterrupt {
 			// interrupt received
 			err = handleInterrupt(s, line)
@@ -203,12 +247,18 @@ shell:
 			// reset interrupt counter
 			s.interruptCount = 0
 
+			if s.repeatPending {
+				s.repeatPending = false
+				line = s.lastCommand
+			}
+
 			// normal flow
 			if len(line) == 0 {
 				// no input line
 				continue
 			}
 
+			s.lastCommand = line
 			err = handleInput(s, line)
 		}
 		if err != nil {
@@ -217,6 +267,33 @@ shell:
 	}
 }
 
+// LastCommand returns the last input executed interactively.
+func (s *Shell) LastCommand() []string {
+	return s.lastCommand
+}
+
+// SetRepeatKey sets a key that immediately executes the last command
+// again e.g. readline.CharCtrlY for Ctrl-y. Use 0 to remove the key.
+func (s *Shell) SetRepeatKey(key rune) {
+	if s.removeRepeatKey != nil {
+		s.removeRepeatKey()
+		s.removeRepeatKey = nil
+	}
+	if key == 0 {
+		return
+	}
+	s.removeRepeatKey = s.AddInputFilter(func(r rune) (rune, bool) {
+		if r != key {
+			return r, true
+		}
+		if len(s.lastCommand) == 0 {
+			return r, false
+		}
+		s.repeatPending = true
+		return readline.CharEnter, true
+	})
+}
+
 // Active tells if the shell is active. i.e. Start is previously called.
 func (s *Shell) Active() bool {
 	s.activeMutex.RLock()
@@ -229,21 +306,134 @@ func (s *Shell) Process(args ...string) error {
 	return handleInput(s, args)
 }
 
+// ProcessWithCode is like Process but returns an exit code for the error
+// returned by the command, suitable for os.Exit.
+// Errors are mapped to exit codes with the mapper set by SetExitCodeMapper.
+// The default mapping is 0 for no error, the code of an ExitError and 1 for
+// other errors.
+func (s *Shell) ProcessWithCode(args ...string) int {
+	return s.exitCode(s.Process(args...))
+}
+
+// SetExitCodeMapper sets the function that maps errors to exit codes
+// for ProcessWithCode. Use nil to restore the default mapping.
+func (s *Shell) SetExitCodeMapper(f func(error) int) {
+	s.exitCodeMapper = f
+}
+
+func (s *Shell) exitCode(err error) int {
+	if s.exitCodeMapper != nil {
+		return s.exitCodeMapper(err)
+	}
+	return defaultExitCode(err)
+}
+
+func defaultExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+	return 1
+}
+
+// ExitError is an error with a custom exit code for non-interactive use.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e ExitError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("exit status %d", e.Code)
+	}
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e ExitError) Unwrap() error {
+	return e.Err
+}
+
+// ProcessMulti runs multiple commands using args in a non-interactive mode.
+// Commands are separated by a standalone ";" or "--" argument e.g.
+// ProcessMulti("connect", "prod", ";", "status").
+// It returns the error of each command in the order the commands are run.
+func (s *Shell) ProcessMulti(args ...string) []error {
+	var errs []error
+	for _, cmd := range splitCommands(args) {
+		errs = append(errs, handleInput(s, cmd))
+	}
+	return errs
+}
+
+// splitCommands splits args into commands at separator arguments,
+// empty commands are skipped.
+func splitCommands(args []string) [][]string {
+	var cmds [][]string
+	var cmd []string
+	for _, arg := range args {
+		if arg == ";" || arg == "--" {
+			if len(cmd) > 0 {
+				cmds = append(cmds, cmd)
+			}
+			cmd = nil
+			continue
+		}
+		cmd = append(cmd, arg)
+	}
+	if len(cmd) > 0 {
+		cmds = append(cmds, cmd)
+	}
+	return cmds
+}
+
 func handleInput(s *Shell, line []string) error {
+	err := dispatchInput(s, line)
+	s.Flush()
+	if s.auditLog != nil {
+		if auditErr := s.auditLog.Record(s.identity, line, err); auditErr != nil && err == nil {
+			err = auditErr
+		}
+	}
+	return err
+}
+
+func dispatchInput(s *Shell, line []string) error {
+	s.removeTempCmds(false)
 	handled, err := s.handleCommand(line)
 	if handled || err != nil {
 		return err
 	}
 
+	// Default command
+	if s.defaultCmd != nil {
+		return s.runCmd(s.defaultCmd, line)
+	}
+
 	// Generic handler
 	if s.generic == nil {
 		return errNoHandler
 	}
 	c := newContext(s, nil, line)
+	c.notFound = newNotFoundInfo(s, line)
 	s.generic(c)
 	return c.err
 }
 
+func newNotFoundInfo(s *Shell, line []string) *NotFoundInfo {
+	info := &NotFoundInfo{Name: line[0], Args: line[1:]}
+	for _, cmd := range s.rootCmd.suggest(info.Name, s.cmdAvailable) {
+		if info.Nearest == nil {
+			info.Nearest = cmd
+		}
+		info.Suggestions = append(info.Suggestions, cmd.Name)
+	}
+	return info
+}
+
 func handleInterrupt(s *Shell, line []string) error {
 	if s.interrupt == nil {
 		return errNoInterruptHandler
@@ -270,21 +460,50 @@ func (s *Shell) handleCommand(str []string) (bool, error) {
 	if cmd == nil {
 		return false, nil
 	}
+	if s.usage != nil {
+		s.usage.record(s.rootCmd.findPath(str))
+	}
+	return true, s.runCmd(cmd, args)
+}
+
+func (s *Shell) runCmd(cmd *Cmd, args []string) error {
+	if !s.cmdAvailable(cmd) {
+		if cmd.UnavailableErr != nil {
+			return cmd.UnavailableErr
+		}
+		return errCmdUnavailable
+	}
 	// trigger help if func is not registered or auto help is true
 	if cmd.Func == nil || (s.autoHelp && len(args) == 1 && args[0] == "help") {
-		s.Println(cmd.HelpText())
-		return true, nil
+		s.Println(cmd.helpText(s.cmdAvailable))
+		return nil
+	}
+	if err := cmd.validateArgs(args); err != nil {
+		return err
 	}
 	c := newContext(s, cmd, args)
 	cmd.Func(c)
-	return true, c.err
+	return c.err
+}
+
+// cmdAvailable reports if cmd is available in the current state of the shell.
+func (s *Shell) cmdAvailable(cmd *Cmd) bool {
+	if cmd.Available == nil {
+		return true
+	}
+	return cmd.Available(newContext(s, cmd, nil))
 }
 
 func (s *Shell) readLine() (line string, err error) {
+	s.Flush()
 	consumer := make(chan lineString)
 	defer close(consumer)
 	go s.reader.readLine(consumer)
 	ls := <-consumer
+	s.clearValidation()
+	if ls.err == nil && !s.reader.scanner.Config.DisableAutoSaveHistory {
+		s.addHistory(ls.line)
+	}
 	return ls.line, ls.err
 }
 
@@ -311,10 +530,14 @@ func (s *Shell) read() ([]string, error) {
 	s.rawArgs = strings.Fields(lines)
 
 	if heredoc {
-		s := strings.SplitN(lines, "<<", 2)
-		args, err1 := shlex.Split(s[0])
+		parts := strings.SplitN(lines, "<<", 2)
+		args, err1 := s.split(parts[0])
 
-		arg := strings.TrimSuffix(strings.SplitN(s[1], "\n", 2)[1], eof)
+		// input may end before the first heredoc line.
+		var arg string
+		if body := strings.SplitN(parts[1], "\n", 2); len(body) == 2 {
+			arg = strings.TrimSuffix(body[1], eof)
+		}
 		args = append(args, arg)
 		if err1 != nil {
 			return args, err1
@@ -324,14 +547,32 @@ func (s *Shell) read() ([]string, error) {
 
 	lines = strings.Replace(lines, "\\\n", " \n", -1)
 
-	args, err1 := shlex.Split(lines)
+	args, err1 := s.split(lines)
 	if err1 != nil {
 		return args, err1
 	}
+	if s.expandGlobs {
+		args = s.expandArgs(lines, args)
+	}
 
 	return args, err
 }
 
+// split splits line into arguments using the splitter set by SetSplitter.
+func (s *Shell) split(line string) ([]string, error) {
+	if s.splitter != nil {
+		return s.splitter(line)
+	}
+	return splitWords(line)
+}
+
+// SetSplitter sets the function that splits an input line into arguments.
+// This allows quoting and escaping rules other than the default shell-like
+// rules. Use nil to restore the default.
+func (s *Shell) SetSplitter(f func(line string) ([]string, error)) {
+	s.splitter = f
+}
+
 func (s *Shell) readMultiLinesFunc(f func(string) bool) (string, error) {
 	var lines bytes.Buffer
 	currentLine := 0
@@ -359,13 +600,31 @@ func (s *Shell) readMultiLinesFunc(f func(string) bool) (string, error) {
 }
 
 func (s *Shell) initCompleters() {
-	s.setCompleter(iCompleter{cmd: s.rootCmd, disabled: func() bool { return s.multiChoiceActive }})
+	s.setCompleter(iCompleter{
+		root:      s.RootCmd,
+		disabled:  func() bool { return s.multiChoiceActive },
+		available: s.cmdAvailable,
+		split:     s.split,
+		count:     s.cmdCount,
+	})
 }
 
 func (s *Shell) setCompleter(completer readline.AutoCompleter) {
+	s.UpdateConfig(func(config *readline.Config) {
+		config.AutoComplete = completer
+	})
+}
+
+// UpdateConfig updates the readline config at runtime.
+// f is passed a copy of the current config and the modified copy
+// replaces the active config once f returns.
+func (s *Shell) UpdateConfig(f func(*readline.Config)) {
+	s.configMutex.Lock()
+	defer s.configMutex.Unlock()
 	config := s.reader.scanner.Config.Clone()
-	config.AutoComplete = completer
+	f(config)
 	s.reader.scanner.SetConfig(config)
+	s.restoreHistory()
 }
 
 // CustomCompleter allows use of custom implementation of readline.Autocompleter.
@@ -385,6 +644,58 @@ func (s *Shell) DeleteCmd(name string) {
 	s.rootCmd.DeleteCmd(name)
 }
 
+// DeleteCmdPath deletes the command at path e.g. []string{"remote", "add"}.
+// It returns false if there is no command at path.
+func (s *Shell) DeleteCmdPath(path []string) bool {
+	return s.rootCmd.DeleteCmdPath(path)
+}
+
+// RenameCmd renames the top level command named old to new.
+func (s *Shell) RenameCmd(old, new string) error {
+	return s.rootCmd.RenameCmd(old, new)
+}
+
+type tempCmd struct {
+	cmd     *Cmd
+	expires time.Time
+}
+
+// AddCmdTemp adds a top level command that is only available for the
+// current session. The command is removed when the shell stops or,
+// if ttl is greater than zero, when ttl elapses.
+func (s *Shell) AddCmdTemp(cmd *Cmd, ttl time.Duration) {
+	var expires time.Time
+	if ttl > 0 {
+		expires = time.Now().Add(ttl)
+	}
+	s.AddCmd(cmd)
+	s.tempCmds = append(s.tempCmds, tempCmd{cmd: cmd, expires: expires})
+}
+
+// removeTempCmds removes expired temporary commands or all of them if all is true.
+func (s *Shell) removeTempCmds(all bool) {
+	now := time.Now()
+	var remaining []tempCmd
+	for _, t := range s.tempCmds {
+		if !all && (t.expires.IsZero() || now.Before(t.expires)) {
+			remaining = append(remaining, t)
+			continue
+		}
+		// the command may have been replaced since.
+		if s.rootCmd.children[t.cmd.Name] == t.cmd {
+			s.DeleteCmd(t.cmd.Name)
+		}
+	}
+	s.tempCmds = remaining
+}
+
+// SetDefaultCmd sets the command for inputs that do not match any of
+// the added commands. cmd receives the entire input as args.
+// This takes precedence over NotFound. Use nil to unset.
+func (s *Shell) SetDefaultCmd(cmd *Cmd) {
+	s.defaultCmd = cmd
+}
+
 // NotFound adds a generic function for all inputs.
 // It is called if the shell input could not be handled by any of the
 // added commands.
@@ -408,6 +719,19 @@ func (s *Shell) Interrupt(f func(c *Context, count int, input string)) {
 	s.interrupt = f
 }
 
+// ClearOnInterrupt sets if a keyboard interrupt (Ctrl-c) on a non-empty
+// input line should only discard the line, like bash. The interrupt handler
+// is then only called for interrupts on an empty line. Defaults to false.
+func (s *Shell) ClearOnInterrupt(clear bool) {
+	s.clearOnInterrupt = clear
+}
+
+// SetInterruptPolicy sets the handling of keyboard interrupts (Ctrl-c)
+// to one of the presets e.g. InterruptStop. Defaults to InterruptExit(2).
+func (s *Shell) SetInterruptPolicy(policy InterruptPolicy) {
+	s.Interrupt(policy)
+}
+
 // EOF adds a function to handle End of File input (Ctrl-d).
 // This overrides the default behaviour which terminates the shell.
 func (s *Shell) EOF(f func(c *Context)) {
@@ -416,13 +740,10 @@ func (s *Shell) EOF(f func(c *Context)) {
 
 // SetHistoryPath sets where readlines history file location. Use an empty
 // string to disable history file. It is empty by default.
+// Only the most recent lines are loaded and new lines are appended to
+// the file, large history files do not slow down the shell.
 func (s *Shell) SetHistoryPath(path string) {
-	// Using scanner.SetHistoryPath doesn't initialize things properly and
-	// history file is never written. Simpler to just create a new readline
-	// Instance.
-	config := s.reader.scanner.Config.Clone()
-	config.HistoryFile = path
-	s.reader.scanner, _ = readline.NewEx(config)
+	s.setHistoryFile(path)
 }
 
 // SetHomeHistoryPath is a convenience method that sets the history path
@@ -449,9 +770,32 @@ func (s *Shell) SetHomeHistoryPath(path string) {
 
 // SetOut sets the writer to write outputs to.
 func (s *Shell) SetOut(writer io.Writer) {
+	if s.unbufferedWriter != nil {
+		s.Flush()
+		s.unbufferedWriter = writer
+		writer = bufio.NewWriterSize(writer, outputBufferSize)
+	}
 	s.writer = writer
 }
 
+const outputBufferSize = 64 * 1024
+
+// BufferOutput sets if output should be buffered. This improves performance
+// for commands with large outputs. Buffered output is written when the
+// buffer is full, after each command, before input is read and when Flush
+// is called. Defaults to false.
+func (s *Shell) BufferOutput(buffer bool) {
+	buffered := s.unbufferedWriter != nil
+	if buffer && !buffered {
+		s.unbufferedWriter = s.writer
+		s.writer = bufio.NewWriterSize(s.writer, outputBufferSize)
+	} else if !buffer && buffered {
+		s.Flush()
+		s.writer = s.unbufferedWriter
+		s.unbufferedWriter = nil
+	}
+}
+
 // SetPager sets the pager and its arguments for paged output
 func (s *Shell) SetPager(pager string, args []string) {
 	s.pager = pager
@@ -512,9 +856,8 @@ func (s *Shell) multiChoice(options []string, text string, init []int, multiResu
 	s.ShowPrompt(false)
 	defer s.ShowPrompt(true)
 
-	// TODO this may not work on windows.
-	s.Print("\033[?25l")
-	defer s.Print("\033[?25h")
+	s.HideCursor()
+	defer s.ShowCursor()
 
 	cur := 0
 	if len(selected) > 0 {
@@ -529,7 +872,7 @@ func (s *Shell) multiChoice(options []string, text string, init []int, multiResu
 
 	// move cursor to the top
 	// TODO it happens on every update, however, some trash appears in history without this line
-	s.Print("\033[0;0H")
+	s.MoveCursorTo(1, 1)
 
 	offset := fd
 
@@ -538,7 +881,7 @@ func (s *Shell) multiChoice(options []string, text string, init []int, multiResu
 		if len(strs) > maxRows-1 {
 			strs = strs[offset : maxRows+offset-1]
 		}
-		s.Print("\033[0;0H")
+		s.MoveCursorTo(1, 1)
 		// clear from the cursor to the end of the screen
 		s.Print("\033[0J")
 		s.Println(text)
@@ -664,18 +1007,12 @@ func newContext(s *Shell, cmd *Cmd, args []string) *Context {
 		cmd = &Cmd{}
 	}
 	return &Context{
-		Actions:     s.Actions,
-		progressBar: copyShellProgressBar(s),
-		Args:        args,
-		RawArgs:     s.rawArgs,
-		Cmd:         *cmd,
-		contextValues: func() contextValues {
-			values := contextValues{}
-			for k := range s.contextValues {
-				values[k] = s.contextValues[k]
-			}
-			return values
-		}(),
+		Actions:       s.Actions,
+		progressBar:   copyShellProgressBar(s),
+		Args:          args,
+		RawArgs:       s.rawArgs,
+		Cmd:           *cmd,
+		contextValues: contextValues{values: s.contextValues.copy()},
 	}
 }
 
@@ -690,31 +1027,3 @@ func copyShellProgressBar(s *Shell) ProgressBar {
 	p.Interval(sp.interval)
 	return p
 }
-
-func getPosition() (int, int, error) {
-	fd := int(os.Stdout.Fd())
-	state, err := readline.MakeRaw(fd)
-	if err != nil {
-		return 0, 0, err
-	}
-	defer readline.Restore(fd, state)
-	fmt.Printf("\033[6n")
-	var out string
-	reader := bufio.NewReader(os.Stdin)
-	for {
-		b, err := reader.ReadByte()
-		if err != nil || b == 'R' {
-			break
-		}
-		if unicode.IsPrint(rune(b)) {
-			out += string(b)
-		}
-	}
-	var row, col int
-	_, err = fmt.Sscanf(out, "[%d;%d", &row, &col)
-	if err != nil {
-		return 0, 0, err
-	}
-
-	return col, row, nil
-}
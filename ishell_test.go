@@ -0,0 +1,211 @@
+package ishell_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/abiosoft/ishell/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCmdAvailable(t *testing.T) {
+	shell := ishell.New()
+	available := false
+	ran := false
+	shell.AddCmd(&ishell.Cmd{
+		Name:      "status",
+		Help:      "show status",
+		Func:      func(c *ishell.Context) { ran = true },
+		Available: func(c *ishell.Context) bool { return available },
+	})
+
+	assert.NotContains(t, shell.HelpText(), "status")
+	assert.Error(t, shell.Process("status"))
+	assert.False(t, ran, "unavailable command should not run")
+
+	available = true
+	assert.Contains(t, shell.HelpText(), "status")
+	assert.NoError(t, shell.Process("status"))
+	assert.True(t, ran, "available command should run")
+}
+
+func TestProcessMulti(t *testing.T) {
+	shell := ishell.New()
+	var ran []string
+	for _, name := range []string{"connect", "status"} {
+		shell.AddCmd(&ishell.Cmd{
+			Name: name,
+			Func: func(c *ishell.Context) { ran = append(ran, c.Cmd.Name) },
+		})
+	}
+	errs := shell.ProcessMulti("connect", "prod", ";", "unknown", "--", "status")
+	assert.Len(t, errs, 3)
+	assert.NoError(t, errs[0])
+	assert.Error(t, errs[1])
+	assert.NoError(t, errs[2])
+	assert.Equal(t, []string{"connect", "status"}, ran)
+}
+
+func TestProcessWithCode(t *testing.T) {
+	shell := ishell.New()
+	shell.AddCmd(&ishell.Cmd{
+		Name: "fail",
+		Func: func(c *ishell.Context) { c.Err(ishell.ExitError{Code: 3}) },
+	})
+	shell.AddCmd(&ishell.Cmd{
+		Name: "ok",
+		Func: func(c *ishell.Context) {},
+	})
+	assert.Equal(t, 0, shell.ProcessWithCode("ok"))
+	assert.Equal(t, 3, shell.ProcessWithCode("fail"))
+	assert.Equal(t, 1, shell.ProcessWithCode("unknown"))
+
+	shell.SetExitCodeMapper(func(err error) int { return 0 })
+	assert.Equal(t, 0, shell.ProcessWithCode("fail"))
+}
+
+func TestNotFoundInfo(t *testing.T) {
+	shell := ishell.New()
+	shell.AddCmd(&ishell.Cmd{Name: "status", Func: func(c *ishell.Context) {}})
+	var info *ishell.NotFoundInfo
+	shell.NotFound(func(c *ishell.Context) {
+		info = c.NotFoundInfo()
+	})
+	assert.NoError(t, shell.Process("statsu", "now"))
+	assert.NotNil(t, info)
+	assert.Equal(t, "statsu", info.Name)
+	assert.Equal(t, []string{"now"}, info.Args)
+	assert.Equal(t, []string{"status"}, info.Suggestions)
+	assert.Equal(t, "status", info.Nearest.Name)
+}
+
+func TestDefaultCmd(t *testing.T) {
+	shell := ishell.New()
+	var args []string
+	shell.SetDefaultCmd(&ishell.Cmd{
+		Name: "eval",
+		Func: func(c *ishell.Context) { args = c.Args },
+	})
+	assert.NoError(t, shell.Process("1", "+", "2"))
+	assert.Equal(t, []string{"1", "+", "2"}, args)
+}
+
+func TestNavigationCmds(t *testing.T) {
+	shell := ishell.New()
+	shell.AddNavigationCmds()
+	dir := t.TempDir()
+	assert.NoError(t, shell.Process("cd", dir))
+	assert.Equal(t, dir, shell.Dir())
+	assert.Equal(t, filepath.Join(dir, "file"), shell.AbsPath("file"))
+	assert.Error(t, shell.Process("cd", "missing"))
+	assert.Equal(t, dir, shell.Dir())
+}
+
+func TestFileSystem(t *testing.T) {
+	shell := ishell.New()
+	shell.AddNavigationCmds()
+	shell.SetFileSystem(fstest.MapFS{
+		"etc/hosts": &fstest.MapFile{},
+	})
+	assert.Equal(t, "/", shell.Dir())
+	assert.NoError(t, shell.Process("cd", "etc"))
+	assert.Equal(t, "/etc", shell.Dir())
+	entries, err := shell.ReadDir(".")
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Error(t, shell.Process("cd", "hosts"))
+}
+
+func TestAuditLog(t *testing.T) {
+	shell := ishell.New()
+	shell.AddCmd(&ishell.Cmd{Name: "deploy", Func: func(c *ishell.Context) {}})
+	var buf bytes.Buffer
+	key := []byte("secret")
+	shell.SetAuditLog(ishell.NewAuditLog(&buf, key))
+	shell.Process("deploy", "prod")
+	shell.Process("unknown")
+	assert.NoError(t, ishell.VerifyAuditLog(bytes.NewReader(buf.Bytes()), key))
+
+	tampered := bytes.Replace(buf.Bytes(), []byte("prod"), []byte("test"), 1)
+	assert.Error(t, ishell.VerifyAuditLog(bytes.NewReader(tampered), key))
+}
+
+func TestSearchCmds(t *testing.T) {
+	shell := ishell.New()
+	remote := &ishell.Cmd{Name: "remote", Help: "manage remotes"}
+	remote.AddCmd(&ishell.Cmd{Name: "add", Help: "add a new remote"})
+	remote.AddCmd(&ishell.Cmd{Name: "rm", Aliases: []string{"delete"}})
+	shell.AddCmd(remote)
+
+	results := shell.SearchCmds("DELETE")
+	assert.Len(t, results, 1)
+	assert.Equal(t, []string{"remote", "rm"}, results[0].Path)
+	assert.Len(t, shell.SearchCmds("remote"), 2)
+}
+
+func TestParamValues(t *testing.T) {
+	shell := ishell.New()
+	shell.AddCmd(&ishell.Cmd{
+		Name:   "log",
+		Func:   func(c *ishell.Context) {},
+		Params: []ishell.Param{{Name: "level", Values: []string{"debug", "info"}}},
+	})
+	assert.NoError(t, shell.Process("log", "info"))
+	assert.Error(t, shell.Process("log", "trace"))
+}
+
+func TestModes(t *testing.T) {
+	shell := ishell.New()
+	config := &ishell.Cmd{}
+	config.AddCmd(&ishell.Cmd{Name: "hostname", Func: func(c *ishell.Context) {}})
+	shell.AddCmd(&ishell.Cmd{
+		Name: "configure",
+		Func: func(c *ishell.Context) { c.PushMode("config", config) },
+	})
+
+	assert.NoError(t, shell.Process("configure"))
+	assert.Equal(t, []string{"config"}, shell.Modes())
+	assert.NoError(t, shell.Process("hostname"))
+	assert.Error(t, shell.Process("configure"))
+
+	assert.NoError(t, shell.Process("exit"))
+	assert.Empty(t, shell.Modes())
+	assert.Error(t, shell.Process("hostname"))
+}
+
+func TestReadInput(t *testing.T) {
+	cases := []struct {
+		input string
+		args  []string
+	}{
+		{"greet someone\n", []string{"greet", "someone"}},
+		{"greet héllo wörld\n", []string{"greet", "héllo", "wörld"}},
+		{"greet 'some one' \"s\\\"q\"\n", []string{"greet", "some one", "s\"q"}},
+		{"greet some \\\none\n", []string{"greet", "some", "one"}},
+		{"greet a#b # comment\n", []string{"greet", "a#b"}},
+		{"cat << EOF\nline 1\nline 2\nEOF\n", []string{"cat", "line 1\nline 2\n"}},
+		{"cat << EOF", []string{"cat", ""}},
+	}
+	for _, c := range cases {
+		args, _ := ishell.ReadInput(c.input)
+		assert.Equal(t, c.args, args, c.input)
+	}
+}
+
+func FuzzRead(f *testing.F) {
+	for _, input := range []string{
+		"greet someone\n",
+		"greet 'some one' \"s\\\"q\"\n",
+		"greet some \\\none\n",
+		"cat << EOF\nline\nEOF\n",
+		"cat <<\n",
+		"héllo 世界\n",
+	} {
+		f.Add(input)
+	}
+	f.Fuzz(func(t *testing.T, input string) {
+		ishell.ReadInput(input)
+	})
+}
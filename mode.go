@@ -0,0 +1,83 @@
+package ishell
+
+import (
+	"strings"
+	"unicode"
+)
+
+// mode is a nested shell mode with its own commands.
+type mode struct {
+	name string
+	root *Cmd
+}
+
+// PushMode enters a nested mode named name e.g. "config".
+// If root is not nil, its subcommands replace the commands of the shell
+// until the mode is exited. exit and help commands are added to root if
+// missing, exit leaves the mode.
+// The prompt shows the names of the active modes, see SetBreadcrumbFunc.
+func (s *Shell) PushMode(name string, root *Cmd) {
+	s.modes = append(s.modes, mode{name: name, root: s.rootCmd})
+	if root != nil {
+		if root.findChildCmd("exit") == nil {
+			root.AddCmd(&Cmd{Name: "exit", Help: "exit the mode", Func: exitFunc})
+		}
+		if root.findChildCmd("help") == nil {
+			root.AddCmd(&Cmd{Name: "help", Help: "display help", Func: helpFunc})
+		}
+		s.rootCmd = root
+	}
+	s.applyPrompt()
+}
+
+// PopMode exits the current mode and restores the commands of the
+// previous mode. It returns false if the shell is not in a mode.
+func (s *Shell) PopMode() bool {
+	if len(s.modes) == 0 {
+		return false
+	}
+	last := s.modes[len(s.modes)-1]
+	s.modes = s.modes[:len(s.modes)-1]
+	s.rootCmd = last.root
+	s.applyPrompt()
+	return true
+}
+
+// Modes returns the names of the active modes, outermost first.
+func (s *Shell) Modes() []string {
+	var names []string
+	for _, m := range s.modes {
+		names = append(names, m.name)
+	}
+	return names
+}
+
+// SetBreadcrumbFunc sets the function that builds the prompt from the
+// prompt set by SetPrompt and the names of the active modes.
+// The default inserts the modes before the trailing symbols of the prompt
+// e.g. "router# " becomes "router(config/if)# ".
+func (s *Shell) SetBreadcrumbFunc(f func(prompt string, modes []string) string) {
+	s.breadcrumb = f
+	s.applyPrompt()
+}
+
+// applyPrompt sets the prompt of the reader from the base prompt and modes.
+func (s *Shell) applyPrompt() {
+	prompt := s.basePrompt
+	if len(s.modes) > 0 {
+		breadcrumb := s.breadcrumb
+		if breadcrumb == nil {
+			breadcrumb = defaultBreadcrumb
+		}
+		prompt = breadcrumb(prompt, s.Modes())
+	}
+	s.reader.prompt = prompt
+	s.reader.scanner.SetPrompt(s.reader.rlPrompt())
+}
+
+func defaultBreadcrumb(prompt string, modes []string) string {
+	i := strings.LastIndexFunc(prompt, func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsDigit(r)
+	})
+	return prompt[:i+1] + "(" + strings.Join(modes, "/") + ")" + prompt[i+1:]
+}
@@ -0,0 +1,217 @@
+package ishell
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var errNotDir = errors.New("not a directory")
+
+// SetFileSystem sets the filesystem used for navigation, path completion
+// and glob expansion e.g. a remote filesystem over SFTP.
+// Paths in fsys are presented to the user as absolute slash-separated paths
+// i.e. "/" is the root of fsys. Use nil to restore the local filesystem.
+// This resets the working directory of the shell.
+func (s *Shell) SetFileSystem(fsys fs.FS) {
+	s.fsys = fsys
+	s.dir = ""
+}
+
+// Dir returns the working directory of the shell.
+// It defaults to the working directory of the process or the root
+// of the filesystem set by SetFileSystem.
+func (s *Shell) Dir() string {
+	if s.dir == "" {
+		if s.fsys != nil {
+			return "/"
+		}
+		if wd, err := os.Getwd(); err == nil {
+			return wd
+		}
+	}
+	return s.dir
+}
+
+// SetDir sets the working directory of the shell. Relative paths are
+// resolved against the current working directory of the shell.
+// The working directory of the process is not changed.
+func (s *Shell) SetDir(dir string) error {
+	dir = s.absPath(dir)
+	info, err := s.stat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return errNotDir
+	}
+	s.dir = dir
+	return nil
+}
+
+// absPath resolves p against the working directory of the shell.
+func (s *Shell) absPath(p string) string {
+	if s.fsys != nil {
+		if path.IsAbs(p) {
+			return path.Clean(p)
+		}
+		return path.Join(s.Dir(), p)
+	}
+	if strings.HasPrefix(p, "~") {
+		if home, err := os.UserHomeDir(); err == nil {
+			p = home + p[1:]
+		}
+	}
+	if filepath.IsAbs(p) {
+		return filepath.Clean(p)
+	}
+	return filepath.Join(s.Dir(), p)
+}
+
+// fsPath converts the absolute path p to a path in the filesystem
+// set by SetFileSystem.
+func fsPath(p string) string {
+	p = strings.TrimPrefix(path.Clean(p), "/")
+	if p == "" {
+		return "."
+	}
+	return p
+}
+
+func (s *Shell) stat(p string) (fs.FileInfo, error) {
+	if s.fsys != nil {
+		return fs.Stat(s.fsys, fsPath(p))
+	}
+	return os.Stat(p)
+}
+
+func (s *Shell) readDir(p string) ([]fs.DirEntry, error) {
+	if s.fsys != nil {
+		return fs.ReadDir(s.fsys, fsPath(p))
+	}
+	return os.ReadDir(p)
+}
+
+// glob returns the paths matching the absolute pattern.
+func (s *Shell) glob(pattern string) ([]string, error) {
+	if s.fsys == nil {
+		return filepath.Glob(pattern)
+	}
+	matches, err := fs.Glob(s.fsys, fsPath(pattern))
+	for i := range matches {
+		matches[i] = "/" + matches[i]
+	}
+	return matches, err
+}
+
+// separator returns the path separator of the filesystem.
+func (s *Shell) separator() string {
+	if s.fsys != nil {
+		return "/"
+	}
+	return string(filepath.Separator)
+}
+
+// relPath returns p relative to the working directory of the shell.
+func (s *Shell) relPath(p string) (string, error) {
+	if s.fsys != nil {
+		rel, err := filepath.Rel(filepath.FromSlash(s.Dir()), filepath.FromSlash(p))
+		return filepath.ToSlash(rel), err
+	}
+	return filepath.Rel(s.Dir(), p)
+}
+
+// AddNavigationCmds adds the cd, pwd and ls commands for navigating
+// the filesystem. The commands change the working directory of the
+// shell which is available to commands with Context.Dir.
+func (s *Shell) AddNavigationCmds() {
+	s.AddCmd(&Cmd{
+		Name:                "cd",
+		Help:                "change the working directory",
+		Func:                cdFunc,
+		CompleterWithPrefix: s.PathCompleter(true),
+	})
+	s.AddCmd(&Cmd{
+		Name: "pwd",
+		Help: "print the working directory",
+		Func: pwdFunc,
+	})
+	s.AddCmd(&Cmd{
+		Name:                "ls",
+		Help:                "list directory contents",
+		Func:                lsFunc,
+		CompleterWithPrefix: s.PathCompleter(false),
+	})
+}
+
+// PathCompleter returns a completer for paths relative to the working
+// directory of the shell. Only directories are completed if dirsOnly is true.
+func (s *Shell) PathCompleter(dirsOnly bool) func(prefix string, args []string) []string {
+	return func(prefix string, args []string) []string {
+		i := strings.LastIndexAny(prefix, "/"+s.separator())
+		dir, base := prefix[:i+1], prefix[i+1:]
+		entries, err := s.readDir(s.absPath(dir))
+		if err != nil {
+			return nil
+		}
+		var paths []string
+		for _, entry := range entries {
+			name := entry.Name()
+			if !strings.HasPrefix(name, base) || (strings.HasPrefix(name, ".") && !strings.HasPrefix(base, ".")) {
+				continue
+			}
+			if entry.IsDir() {
+				name += s.separator()
+			} else if dirsOnly {
+				continue
+			}
+			paths = append(paths, dir+name)
+		}
+		return paths
+	}
+}
+
+func cdFunc(c *Context) {
+	dir := "~"
+	if len(c.Args) > 0 {
+		dir = c.Args[0]
+	}
+	if err := c.SetDir(dir); err != nil {
+		c.Err(err)
+	}
+}
+
+func pwdFunc(c *Context) {
+	c.Println(c.Dir())
+}
+
+func lsFunc(c *Context) {
+	dir := "."
+	if len(c.Args) > 0 {
+		dir = c.Args[0]
+	}
+	entries, err := c.ReadDir(dir)
+	if err != nil {
+		c.Err(err)
+		return
+	}
+	var names []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+		if entry.IsDir() {
+			name += "/"
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		c.Println(name)
+	}
+}
@@ -44,6 +44,12 @@ type ProgressBar interface {
 	Start()
 	// Stop stops the progress bar.
 	Stop()
+	// WrapReader returns a reader that reads from r and updates the
+	// progress as bytes are read. total is the expected number of bytes.
+	WrapReader(r io.Reader, total int64) io.Reader
+	// WrapWriter returns a writer that writes to w and updates the
+	// progress as bytes are written. total is the expected number of bytes.
+	WrapWriter(w io.Writer, total int64) io.Writer
 }
 
 const progressInterval = time.Millisecond * 100
@@ -187,6 +193,51 @@ func (p *progressBarImpl) Stop() {
 	<-p.wait
 }
 
+func (p *progressBarImpl) WrapReader(r io.Reader, total int64) io.Reader {
+	return &progressReader{Reader: r, counter: progressCounter{bar: p, total: total}}
+}
+
+func (p *progressBarImpl) WrapWriter(w io.Writer, total int64) io.Writer {
+	return &progressWriter{Writer: w, counter: progressCounter{bar: p, total: total}}
+}
+
+// progressCounter updates a progress bar from a byte count.
+type progressCounter struct {
+	bar     ProgressBar
+	total   int64
+	current int64
+}
+
+func (p *progressCounter) add(n int) {
+	if n <= 0 || p.total <= 0 {
+		return
+	}
+	p.current += int64(n)
+	p.bar.Progress(int(p.current * 100 / p.total))
+}
+
+type progressReader struct {
+	io.Reader
+	counter progressCounter
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.Reader.Read(b)
+	p.counter.add(n)
+	return n, err
+}
+
+type progressWriter struct {
+	io.Writer
+	counter progressCounter
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.Writer.Write(b)
+	p.counter.add(n)
+	return n, err
+}
+
 // ProgressDisplayCharSet is the character set for
 // a progress bar.
 type ProgressDisplayCharSet []string
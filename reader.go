@@ -2,7 +2,6 @@ package ishell
 
 import (
 	"bytes"
-	"strings"
 	"sync"
 
 	"github.com/abiosoft/readline"
@@ -42,12 +41,32 @@ func (s *shellReader) rlPrompt() string {
 }
 
 func (s *shellReader) readPasswordErr() (string, error) {
-	prompt := ""
+	return s.readPasswordWithOptions(PasswordOptions{})
+}
+
+func (s *shellReader) readPasswordWithOptions(opts PasswordOptions) (string, error) {
+	config := s.scanner.GenPasswordConfig()
 	if s.buf.Len() > 0 {
-		prompt = s.buf.String()
+		config.Prompt = s.buf.String()
 		s.buf.Truncate(0)
 	}
-	password, err := s.scanner.ReadPassword(prompt)
+	if opts.ShowLast {
+		mask := opts.Mask
+		if mask == 0 {
+			mask = '*'
+		}
+		config.EnableMask = false
+		config.Painter = maskPainter(mask)
+	} else {
+		config.MaskRune = opts.Mask
+	}
+	if opts.OnChange != nil {
+		config.SetListener(func(line []rune, pos int, key rune) ([]rune, int, bool) {
+			opts.OnChange(line)
+			return nil, 0, false
+		})
+	}
+	password, err := s.scanner.ReadPasswordWithConfig(config)
 	return string(password), err
 }
 
@@ -78,11 +97,11 @@ func (s *shellReader) readLine(consumer chan lineString) {
 	shellPrompt := s.prompt
 	prompt := s.rlPrompt()
 	if s.buf.Len() > 0 {
-		lines := strings.Split(s.buf.String(), "\n")
-		if p := lines[len(lines)-1]; strings.TrimSpace(p) != "" {
-			prompt = p
+		b := s.buf.Bytes()
+		if p := b[bytes.LastIndexByte(b, '\n')+1:]; len(bytes.TrimSpace(p)) > 0 {
+			prompt = string(p)
 		}
-		s.buf.Truncate(0)
+		s.buf.Reset()
 	}
 
 	// use printed statement as prompt
@@ -0,0 +1,27 @@
+package ishell
+
+import (
+	"os"
+	"os/signal"
+)
+
+// OnStop adds a function to be called when the shell stops.
+func (s *Shell) OnStop(f func()) {
+	s.stopHooks = append(s.stopHooks, f)
+}
+
+// HandleSignals stops and closes the shell gracefully when any of signals
+// is received e.g. syscall.SIGTERM, syscall.SIGHUP. The terminal is restored
+// from raw mode and OnStop functions are called.
+func (s *Shell) HandleSignals(signals ...os.Signal) {
+	if len(signals) == 0 {
+		return
+	}
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, signals...)
+	go func() {
+		<-c
+		signal.Stop(c)
+		s.Close()
+	}()
+}
@@ -0,0 +1,86 @@
+package ishell
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	errEscapeEOF = errors.New("EOF found after escape character")
+	errQuoteEOF  = errors.New("EOF found when expecting closing quote")
+)
+
+// splitWords splits line into words using shell-like rules for quoting,
+// escaping, spaces and comments. Unlike go-shlex, any rune that is not
+// special is accepted as part of a word, including non-ASCII runes.
+func splitWords(line string) ([]string, error) {
+	words := []string{}
+	var word strings.Builder
+	inWord := false
+	for i := 0; i < len(line); {
+		r, size := utf8.DecodeRuneInString(line[i:])
+		i += size
+		switch {
+		case r == ' ' || r == '\t' || r == '\r' || r == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		case r == '#' && !inWord:
+			// comment until end of line.
+			if n := strings.IndexByte(line[i:], '\n'); n >= 0 {
+				i += n + 1
+			} else {
+				i = len(line)
+			}
+		case r == '\\':
+			if i == len(line) {
+				return words, errEscapeEOF
+			}
+			_, size = utf8.DecodeRuneInString(line[i:])
+			word.WriteString(line[i : i+size])
+			i += size
+			inWord = true
+		case r == '\'':
+			n := strings.IndexByte(line[i:], '\'')
+			if n < 0 {
+				return words, errQuoteEOF
+			}
+			word.WriteString(line[i : i+n])
+			i += n + 1
+			inWord = true
+		case r == '"':
+			closed := false
+			for i < len(line) && !closed {
+				r, size = utf8.DecodeRuneInString(line[i:])
+				i += size
+				switch r {
+				case '"':
+					closed = true
+				case '\\':
+					if i == len(line) {
+						return words, errEscapeEOF
+					}
+					_, size = utf8.DecodeRuneInString(line[i:])
+					word.WriteString(line[i : i+size])
+					i += size
+				default:
+					word.WriteString(line[i-size : i])
+				}
+			}
+			if !closed {
+				return words, errQuoteEOF
+			}
+			inWord = true
+		default:
+			word.WriteString(line[i-size : i])
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
@@ -0,0 +1,99 @@
+package ishell
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/abiosoft/readline"
+	"github.com/fatih/color"
+)
+
+var errTerminalUsage = errors.New("usage: terminal [width <columns|auto> | color <on|off> | pager <on|off>]")
+
+// SetWidth sets the width of the terminal in columns used for rendering
+// input. Use 0 to detect the width from the terminal.
+func (s *Shell) SetWidth(width int) {
+	s.width = width
+	s.UpdateConfig(func(config *readline.Config) {
+		if width > 0 {
+			config.FuncGetWidth = func() int { return width }
+		} else {
+			config.FuncGetWidth = readline.GetScreenWidth
+		}
+	})
+}
+
+// SetColor sets if colored output is enabled.
+func (s *Shell) SetColor(enable bool) {
+	color.NoColor = !enable
+}
+
+// SetPagerEnabled sets if ShowPaged and ShowPagedReader should use the pager.
+// If disabled, the text is written to the output directly.
+func (s *Shell) SetPagerEnabled(enable bool) {
+	s.pagerDisabled = !enable
+}
+
+func terminalFunc(s *Shell) func(c *Context) {
+	return func(c *Context) {
+		if len(c.Args) == 0 {
+			width := "auto"
+			if s.width > 0 {
+				width = strconv.Itoa(s.width)
+			}
+			c.Println("width:", width)
+			c.Println("color:", onOff(!color.NoColor))
+			c.Println("pager:", onOff(!s.pagerDisabled))
+			return
+		}
+		if len(c.Args) != 2 {
+			c.Err(errTerminalUsage)
+			return
+		}
+		setting, value := c.Args[0], c.Args[1]
+		switch setting {
+		case "width":
+			if value == "auto" {
+				s.SetWidth(0)
+				return
+			}
+			width, err := strconv.Atoi(value)
+			if err != nil || width <= 0 {
+				c.Err(fmt.Errorf("invalid width '%s'", value))
+				return
+			}
+			s.SetWidth(width)
+		case "color", "pager":
+			enable, err := parseOnOff(value)
+			if err != nil {
+				c.Err(err)
+				return
+			}
+			if setting == "color" {
+				s.SetColor(enable)
+			} else {
+				s.SetPagerEnabled(enable)
+			}
+		default:
+			c.Err(errTerminalUsage)
+		}
+	}
+}
+
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
+
+func parseOnOff(s string) (bool, error) {
+	switch s {
+	case "on":
+		return true, nil
+	case "off":
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid value '%s', expected on or off", s)
+}
@@ -0,0 +1,88 @@
+package ishell
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/abiosoft/readline"
+)
+
+const cursorPositionTimeout = time.Millisecond * 500
+
+var (
+	errNotTerminal      = errors.New("not a terminal")
+	errNoCursorPosition = errors.New("terminal did not report cursor position")
+)
+
+// terminalState is the state of the terminal when the shell is created.
+type terminalState struct {
+	fd    int
+	state *readline.State
+}
+
+func saveTerminalState() *terminalState {
+	fd := readline.GetStdin()
+	if !readline.IsTerminal(fd) {
+		return nil
+	}
+	state, err := readline.GetState(fd)
+	if err != nil {
+		return nil
+	}
+	return &terminalState{fd: fd, state: state}
+}
+
+// RestoreTerminal restores the terminal to its state when the shell was
+// created and shows the cursor if hidden. The shell does this when closed
+// or if a command panics, this can be used by the program in its own
+// panic or fatal error handling.
+func (s *Shell) RestoreTerminal() {
+	if s.termState == nil {
+		return
+	}
+	readline.Restore(s.termState.fd, s.termState.state)
+	showCursor(s)
+}
+
+// restoreOnPanic restores the terminal and resumes panicking if there
+// is a panic. It must be deferred.
+func (s *Shell) restoreOnPanic() {
+	if r := recover(); r != nil {
+		s.RestoreTerminal()
+		panic(r)
+	}
+}
+
+// cursorPosition queries the terminal for the cursor position.
+// The response is read through readline to not compete with it for input.
+func (s *Shell) cursorPosition() (row, col int, err error) {
+	fd := readline.GetStdin()
+	if !readline.IsTerminal(fd) {
+		return 0, 0, errNotTerminal
+	}
+	state, err := readline.MakeRaw(fd)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer readline.Restore(fd, state)
+
+	offset := make(chan string, 1)
+	terminal := s.reader.scanner.Terminal
+	terminal.KickRead()
+	terminal.GetOffset(func(o string) {
+		select {
+		case offset <- o:
+		default:
+		}
+	})
+	select {
+	case o := <-offset:
+		if _, err := fmt.Sscanf(o, "%d;%d", &row, &col); err != nil {
+			return 0, 0, err
+		}
+		return row, col, nil
+	case <-time.After(cursorPositionTimeout):
+		return 0, 0, errNoCursorPosition
+	}
+}
@@ -0,0 +1,155 @@
+package ishell
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path"
+)
+
+var errTransferArgs = errors.New("missing source, try 'help'")
+
+// Fetcher fetches the content of source starting from offset,
+// offset is greater than zero when resuming a partial download.
+// It returns the content and its total size including offset,
+// or -1 if the size is unknown.
+type Fetcher func(ctx context.Context, source string, offset int64) (io.ReadCloser, int64, error)
+
+// Uploader uploads the content of r to destination.
+// size is the number of bytes to be read from r.
+type Uploader func(ctx context.Context, destination string, r io.Reader, size int64) error
+
+// NewDownloadCmd creates a command that downloads a source using fetch.
+// Usage is "name <source> [destination]" and destination defaults to
+// the base name of source.
+// Partial downloads are resumed if destination already exists and
+// the download is cancelled with Ctrl-c.
+func NewDownloadCmd(name string, fetch Fetcher) *Cmd {
+	return &Cmd{
+		Name: name,
+		Help: "download a file",
+		LongHelp: fmt.Sprintf("usage: %s <source> [destination]\n"+
+			"download source to destination. partial downloads are resumed.", name),
+		Func: func(c *Context) {
+			if len(c.Args) == 0 {
+				c.Err(errTransferArgs)
+				return
+			}
+			source, destination := c.Args[0], path.Base(c.Args[0])
+			if len(c.Args) > 1 {
+				destination = c.Args[1]
+			}
+			c.Err(download(c, fetch, source, destination))
+		},
+	}
+}
+
+// NewUploadCmd creates a command that uploads a local file using upload.
+// Usage is "name <source> [destination]" and destination defaults to
+// the base name of source.
+// The upload is cancelled with Ctrl-c.
+func NewUploadCmd(name string, upload Uploader) *Cmd {
+	return &Cmd{
+		Name: name,
+		Help: "upload a file",
+		LongHelp: fmt.Sprintf("usage: %s <source> [destination]\n"+
+			"upload local file source to destination.", name),
+		Func: func(c *Context) {
+			if len(c.Args) == 0 {
+				c.Err(errTransferArgs)
+				return
+			}
+			source, destination := c.Args[0], path.Base(c.Args[0])
+			if len(c.Args) > 1 {
+				destination = c.Args[1]
+			}
+			c.Err(uploadFile(c, upload, source, destination))
+		},
+	}
+}
+
+func download(c *Context, fetch Fetcher, source, destination string) error {
+	f, err := os.OpenFile(destination, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	offset := stat.Size()
+
+	ctx, cancel := interruptContext()
+	defer cancel()
+
+	r, total, err := fetch(ctx, source, offset)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	return transfer(ctx, c, total, offset, func(p ProgressBar) error {
+		w := &progressWriter{Writer: f, counter: progressCounter{bar: p, total: total, current: offset}}
+		_, err := io.Copy(w, r)
+		return err
+	})
+}
+
+func uploadFile(c *Context, upload Uploader, source, destination string) error {
+	f, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := interruptContext()
+	defer cancel()
+
+	return transfer(ctx, c, stat.Size(), 0, func(p ProgressBar) error {
+		return upload(ctx, destination, p.WrapReader(f, stat.Size()), stat.Size())
+	})
+}
+
+// transfer runs f with the context's progress bar. The progress bar is
+// determinate if total is known.
+func transfer(ctx context.Context, c *Context, total, offset int64, f func(ProgressBar) error) error {
+	p := c.ProgressBar()
+	p.Indeterminate(total <= 0)
+	if total > 0 {
+		p.Progress(int(offset * 100 / total))
+	}
+	p.Start()
+	err := f(p)
+	if ctx.Err() != nil {
+		p.Final("cancelled")
+		err = ctx.Err()
+	} else if err == nil {
+		p.Final("done")
+	}
+	p.Stop()
+	return err
+}
+
+// interruptContext returns a context that is cancelled on keyboard interrupt.
+func interruptContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt)
+	go func() {
+		select {
+		case <-sig:
+			cancel()
+		case <-ctx.Done():
+		}
+		signal.Stop(sig)
+	}()
+	return ctx, cancel
+}
@@ -0,0 +1,146 @@
+package ishell
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const usageListSize = 10
+
+// cmdUsage tracks how often and how recently commands are used.
+type cmdUsage struct {
+	path    string
+	records map[string]*usageRecord
+	sync.Mutex
+}
+
+type usageRecord struct {
+	Count int       `json:"count"`
+	Last  time.Time `json:"last"`
+}
+
+func loadCmdUsage(path string) (*cmdUsage, error) {
+	u := &cmdUsage{path: path, records: make(map[string]*usageRecord)}
+	if path == "" {
+		return u, nil
+	}
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return u, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &u.records); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+func (u *cmdUsage) record(cmdPath []string) {
+	u.Lock()
+	defer u.Unlock()
+	key := strings.Join(cmdPath, " ")
+	r, ok := u.records[key]
+	if !ok {
+		r = &usageRecord{}
+		u.records[key] = r
+	}
+	r.Count++
+	r.Last = time.Now()
+	u.save()
+}
+
+// save persists the records, errors are ignored as usage
+// tracking is not critical.
+func (u *cmdUsage) save() {
+	if u.path == "" {
+		return
+	}
+	if b, err := json.Marshal(u.records); err == nil {
+		os.WriteFile(u.path, b, 0600)
+	}
+}
+
+func (u *cmdUsage) count(cmdPath []string) int {
+	u.Lock()
+	defer u.Unlock()
+	if r, ok := u.records[strings.Join(cmdPath, " ")]; ok {
+		return r.Count
+	}
+	return 0
+}
+
+// sorted returns the commands ordered by less, at most n of them.
+func (u *cmdUsage) sorted(n int, less func(a, b *usageRecord) bool) []string {
+	u.Lock()
+	defer u.Unlock()
+	var cmds []string
+	for cmd := range u.records {
+		cmds = append(cmds, cmd)
+	}
+	sort.Slice(cmds, func(i, j int) bool {
+		return less(u.records[cmds[i]], u.records[cmds[j]])
+	})
+	if len(cmds) > n {
+		cmds = cmds[:n]
+	}
+	return cmds
+}
+
+// TrackUsage enables tracking of how often and how recently commands are
+// used and adds the favorites and recent commands to list them.
+// The usage is persisted to path, if path is empty it defaults to the
+// history file with a ".usage" suffix or in memory if there is no
+// history file.
+// If boost is true, frequently used commands are listed first in
+// autocomplete.
+func (s *Shell) TrackUsage(path string, boost bool) error {
+	if path == "" && s.history.file != "" {
+		path = s.history.file + ".usage"
+	}
+	usage, err := loadCmdUsage(path)
+	if err != nil {
+		return err
+	}
+	s.usage = usage
+	s.boostFrequent = boost
+	s.AddCmd(&Cmd{
+		Name: "favorites",
+		Help: "list the most frequently used commands",
+		Func: func(c *Context) {
+			printUsage(c, usage.sorted(usageListSize, func(a, b *usageRecord) bool {
+				return a.Count > b.Count
+			}))
+		},
+	})
+	s.AddCmd(&Cmd{
+		Name: "recent",
+		Help: "list the most recently used commands",
+		Func: func(c *Context) {
+			printUsage(c, usage.sorted(usageListSize, func(a, b *usageRecord) bool {
+				return a.Last.After(b.Last)
+			}))
+		},
+	})
+	return nil
+}
+
+func printUsage(c *Context, cmds []string) {
+	for i, cmd := range cmds {
+		c.Println(fmt.Sprintf("%3d  %s", i+1, cmd))
+	}
+}
+
+// cmdCount returns the number of times the command at cmdPath is used.
+func (s *Shell) cmdCount(cmdPath []string) int {
+	if s.usage == nil || !s.boostFrequent {
+		return 0
+	}
+	return s.usage.count(cmdPath)
+}
@@ -3,6 +3,8 @@
 package ishell
 
 import (
+	"fmt"
+
 	"github.com/abiosoft/readline"
 )
 
@@ -10,3 +12,23 @@ func clearScreen(s *Shell) error {
 	_, err := readline.ClearScreen(s.writer)
 	return err
 }
+
+func hideCursor(s *Shell) error {
+	_, err := fmt.Fprint(s.writer, "\033[?25l")
+	return err
+}
+
+func showCursor(s *Shell) error {
+	_, err := fmt.Fprint(s.writer, "\033[?25h")
+	return err
+}
+
+func moveCursorTo(s *Shell, row, col int) error {
+	_, err := fmt.Fprintf(s.writer, "\033[%d;%dH", row, col)
+	return err
+}
+
+func clearLine(s *Shell) error {
+	_, err := fmt.Fprint(s.writer, "\r\033[K")
+	return err
+}
@@ -3,9 +3,83 @@
 package ishell
 
 import (
+	"fmt"
+	"syscall"
+	"unsafe"
+
 	"github.com/abiosoft/readline"
 )
 
+var (
+	kernel32                     = syscall.NewLazyDLL("kernel32.dll")
+	procGetConsoleCursorInfo     = kernel32.NewProc("GetConsoleCursorInfo")
+	procSetConsoleCursorInfo     = kernel32.NewProc("SetConsoleCursorInfo")
+	procSetConsoleCursorPosition = kernel32.NewProc("SetConsoleCursorPosition")
+)
+
+type consoleCursorInfo struct {
+	size    uint32
+	visible int32
+}
+
 func clearScreen(s *Shell) error {
 	return readline.ClearScreen(s.writer)
 }
+
+func stdoutHandle() (syscall.Handle, error) {
+	return syscall.GetStdHandle(syscall.STD_OUTPUT_HANDLE)
+}
+
+func setCursorVisible(visible bool) error {
+	handle, err := stdoutHandle()
+	if err != nil {
+		return err
+	}
+	var info consoleCursorInfo
+	r, _, err := procGetConsoleCursorInfo.Call(uintptr(handle), uintptr(unsafe.Pointer(&info)))
+	if r == 0 {
+		return err
+	}
+	info.visible = 0
+	if visible {
+		info.visible = 1
+	}
+	r, _, err = procSetConsoleCursorInfo.Call(uintptr(handle), uintptr(unsafe.Pointer(&info)))
+	if r == 0 {
+		return err
+	}
+	return nil
+}
+
+func hideCursor(s *Shell) error {
+	return setCursorVisible(false)
+}
+
+func showCursor(s *Shell) error {
+	return setCursorVisible(true)
+}
+
+func moveCursorTo(s *Shell, row, col int) error {
+	handle, err := stdoutHandle()
+	if err != nil {
+		return err
+	}
+	if row < 1 {
+		row = 1
+	}
+	if col < 1 {
+		col = 1
+	}
+	// COORD is passed by value as x in the low word and y in the high word.
+	coord := uintptr(uint16(col-1)) | uintptr(uint16(row-1))<<16
+	r, _, err := procSetConsoleCursorPosition.Call(uintptr(handle), coord)
+	if r == 0 {
+		return err
+	}
+	return nil
+}
+
+func clearLine(s *Shell) error {
+	_, err := fmt.Fprint(s.writer, "\r\033[K")
+	return err
+}
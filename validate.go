@@ -0,0 +1,64 @@
+package ishell
+
+import (
+	"fmt"
+
+	"github.com/abiosoft/readline"
+	"github.com/fatih/color"
+)
+
+// SetValidator sets a function that validates the input line as it is typed.
+// The error returned is displayed as a hint below the input line and is
+// updated on every keystroke. Validation does not prevent input from being
+// submitted. Use nil to remove the validator.
+func (s *Shell) SetValidator(f func(line string) error) {
+	s.validator = f
+	var painter readline.Painter = plainPainter{}
+	if f != nil {
+		painter = &validatorPainter{validate: f}
+	}
+	s.UpdateConfig(func(config *readline.Config) {
+		config.Painter = painter
+	})
+}
+
+// validatorPainter paints the validation hint below the input line.
+type validatorPainter struct {
+	validate func(line string) error
+	hinted   bool
+}
+
+const (
+	escSaveCursor    = "\0337"
+	escRestoreCursor = "\0338"
+	escClearLine     = "\033[K"
+	escClearBelow    = "\033[J"
+)
+
+func (v *validatorPainter) Paint(line []rune, pos int) []rune {
+	err := v.validate(string(line))
+	if err == nil {
+		if !v.hinted {
+			return line
+		}
+		v.hinted = false
+		return append(line, []rune(escSaveCursor+"\n"+escClearLine+escRestoreCursor)...)
+	}
+	v.hinted = true
+	hint := color.New(color.FgRed).Sprint(err.Error())
+	return append(line, []rune(escSaveCursor+"\n"+escClearLine+hint+escRestoreCursor)...)
+}
+
+// plainPainter paints the input line as is.
+type plainPainter struct{}
+
+func (plainPainter) Paint(line []rune, pos int) []rune {
+	return line
+}
+
+// clearValidation clears the validation hint after input is submitted.
+func (s *Shell) clearValidation() {
+	if s.validator != nil {
+		fmt.Fprint(s.writer, escClearBelow)
+	}
+}